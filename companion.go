@@ -47,6 +47,14 @@ func (c *Companion) RunBackgroundProcesses(ctx context.Context, wg *sync.WaitGro
 
 	go c.UpdateCompanionRunningState(ctx, &processWg)
 
+	// Start all sensors that push their updates on their own.
+	for _, sensor := range c.sensors {
+		if watcher, ok := sensor.Runner.(entity.Watcher); ok {
+			processWg.Add(1)
+			go c.WatchSensor(ctx, &processWg, sensor, watcher)
+		}
+	}
+
 	processWg.Wait()
 }
 
@@ -61,6 +69,53 @@ func (c *Companion) UpdateCompanionRunningState(ctx context.Context, wg *sync.Wa
 	<-ctx.Done()
 }
 
+// WatchSensor updates a single sensor whenever its Watcher reports a change.
+func (c *Companion) WatchSensor(ctx context.Context, wg *sync.WaitGroup, sensor entity.Sensor, watcher entity.Watcher) {
+	defer wg.Done()
+
+	// Changes that are reported while an update is running
+	// are combined into a single subsequent update.
+	changed := make(chan struct{}, 1)
+	go func() {
+		for {
+			select {
+			case <-changed:
+				c.UpdateSingleSensor(ctx, sensor)
+			case <-ctx.Done():
+				return
+			}
+		}
+	}()
+
+	err := watcher.Watch(ctx, func() {
+		select {
+		case changed <- struct{}{}:
+		default:
+		}
+	})
+	if err != nil {
+		log.Printf("failed to watch sensor %s, falling back to the update interval: %s", sensor, err)
+	}
+}
+
+// UpdateSingleSensor fetches the value of a single sensor and sends it to Home Assistant.
+func (c *Companion) UpdateSingleSensor(ctx context.Context, sensor entity.Sensor) {
+	outputs := entity.NewOutputs()
+
+	var wg sync.WaitGroup
+	wg.Add(1)
+	sensor.Update(ctx, &wg, &outputs)
+
+	data := buildUpdateSensorDataRequests(&outputs, true)
+	if len(data) == 0 {
+		return
+	}
+	err := c.api.UpdateSensorData(ctx, data)
+	if err != nil {
+		log.Printf("failed to update sensor data of %s: %s", sensor, err)
+	}
+}
+
 func (c *Companion) InvalidateAllSensors(ctx context.Context) {
 	outputs := entity.NewOutputs()
 
@@ -30,6 +30,36 @@ var sensorDefinitions = map[string]func(m entity.Meta) entity.SensorDefinition{
 			Unit:       "%",
 		}
 	},
+	"cpu_freq": func(_ entity.Meta) entity.SensorDefinition {
+		return entity.SensorDefinition{
+			Type:        "sensor",
+			Runner:      func(m entity.Meta) entity.Runner { return sensor.NewCPUFreq() },
+			DeviceClass: "frequency",
+			Icon:        "mdi:speedometer",
+			StateClass:  "measurement",
+			Unit:        "MHz",
+		}
+	},
+	"package_power": func(_ entity.Meta) entity.SensorDefinition {
+		return entity.SensorDefinition{
+			Type:        "sensor",
+			Runner:      func(m entity.Meta) entity.Runner { return sensor.NewPackagePower(false) },
+			DeviceClass: "power",
+			Icon:        "mdi:lightning-bolt",
+			StateClass:  "measurement",
+			Unit:        "W",
+		}
+	},
+	"package_energy": func(_ entity.Meta) entity.SensorDefinition {
+		return entity.SensorDefinition{
+			Type:        "sensor",
+			Runner:      func(m entity.Meta) entity.Runner { return sensor.NewPackagePower(true) },
+			DeviceClass: "energy",
+			Icon:        "mdi:lightning-bolt",
+			StateClass:  "total_increasing",
+			Unit:        "kWh",
+		}
+	},
 	"memory": func(_ entity.Meta) entity.SensorDefinition {
 		return entity.SensorDefinition{
 			Type:       "sensor",
@@ -38,6 +68,24 @@ var sensorDefinitions = map[string]func(m entity.Meta) entity.SensorDefinition{
 			StateClass: "measurement",
 		}
 	},
+	"cgroup": func(m entity.Meta) entity.SensorDefinition {
+		if m.GetString("state") == "cpu" {
+			return entity.SensorDefinition{
+				Type:       "sensor",
+				Runner:     func(m entity.Meta) entity.Runner { return sensor.NewCgroup(m) },
+				Icon:       "mdi:gauge",
+				StateClass: "measurement",
+				Unit:       "%",
+			}
+		}
+		return entity.SensorDefinition{
+			Type:       "sensor",
+			Runner:     func(m entity.Meta) entity.Runner { return sensor.NewCgroup(m) },
+			Icon:       "mdi:memory",
+			StateClass: "measurement",
+			Unit:       "MB",
+		}
+	},
 	"power": func(_ entity.Meta) entity.SensorDefinition {
 		return entity.SensorDefinition{
 			Type:        "sensor",
@@ -56,6 +104,13 @@ var sensorDefinitions = map[string]func(m entity.Meta) entity.SensorDefinition{
 			DeviceClass: "timestamp",
 		}
 	},
+	"os_info": func(_ entity.Meta) entity.SensorDefinition {
+		return entity.SensorDefinition{
+			Type:   "sensor",
+			Runner: func(meta entity.Meta) entity.Runner { return sensor.NewOSInfo() },
+			Icon:   "mdi:information-outline",
+		}
+	},
 	"load_avg": func(_ entity.Meta) entity.SensorDefinition {
 		return entity.SensorDefinition{
 			Type:       "sensor",
@@ -64,10 +119,26 @@ var sensorDefinitions = map[string]func(m entity.Meta) entity.SensorDefinition{
 			StateClass: "measurement",
 		}
 	},
-	"webcam": func(_ entity.Meta) entity.SensorDefinition {
+	"pressure": func(_ entity.Meta) entity.SensorDefinition {
+		return entity.SensorDefinition{
+			Type:       "sensor",
+			Runner:     func(meta entity.Meta) entity.Runner { return sensor.NewPressure() },
+			Icon:       "mdi:gauge-full",
+			StateClass: "measurement",
+			Unit:       "%",
+		}
+	},
+	"webcam": func(m entity.Meta) entity.SensorDefinition {
+		if m.GetBool("binary") {
+			return entity.SensorDefinition{
+				Type:   "binary_sensor",
+				Runner: func(m entity.Meta) entity.Runner { return sensor.NewWebCam(m) },
+				Icon:   "mdi:webcam",
+			}
+		}
 		return entity.SensorDefinition{
 			Type:   "sensor",
-			Runner: func(meta entity.Meta) entity.Runner { return sensor.NewWebCam() },
+			Runner: func(m entity.Meta) entity.Runner { return sensor.NewWebCam(m) },
 			Icon:   "mdi:webcam",
 		}
 	},
@@ -80,6 +151,28 @@ var sensorDefinitions = map[string]func(m entity.Meta) entity.SensorDefinition{
 			Unit:       "%",
 		}
 	},
+	"microphone": func(_ entity.Meta) entity.SensorDefinition {
+		return entity.SensorDefinition{
+			Type:   "binary_sensor",
+			Runner: func(m entity.Meta) entity.Runner { return sensor.NewMicrophone(m) },
+			Icon:   "mdi:microphone-off",
+		}
+	},
+	"media_player": func(_ entity.Meta) entity.SensorDefinition {
+		return entity.SensorDefinition{
+			Type:   "sensor",
+			Runner: func(m entity.Meta) entity.Runner { return sensor.NewMediaPlayer(m) },
+			Icon:   "mdi:music",
+		}
+	},
+	"bluetooth": func(_ entity.Meta) entity.SensorDefinition {
+		return entity.SensorDefinition{
+			Type:       "sensor",
+			Runner:     func(meta entity.Meta) entity.Runner { return sensor.NewBluetooth() },
+			Icon:       "mdi:bluetooth",
+			StateClass: "measurement",
+		}
+	},
 	"online_check": func(_ entity.Meta) entity.SensorDefinition {
 		return entity.SensorDefinition{
 			Type:   "binary_sensor",
@@ -87,6 +180,113 @@ var sensorDefinitions = map[string]func(m entity.Meta) entity.SensorDefinition{
 			Icon:   "mdi:shield-check-outline",
 		}
 	},
+	"process": func(m entity.Meta) entity.SensorDefinition {
+		if m.GetBool("binary") {
+			return entity.SensorDefinition{
+				Type:        "binary_sensor",
+				Runner:      func(m entity.Meta) entity.Runner { return sensor.NewProcess(m) },
+				DeviceClass: "running",
+				Icon:        "mdi:application-cog",
+			}
+		}
+		return entity.SensorDefinition{
+			Type:       "sensor",
+			Runner:     func(m entity.Meta) entity.Runner { return sensor.NewProcess(m) },
+			Icon:       "mdi:application-cog",
+			StateClass: "measurement",
+		}
+	},
+	"top_processes": func(_ entity.Meta) entity.SensorDefinition {
+		return entity.SensorDefinition{
+			Type:   "sensor",
+			Runner: func(m entity.Meta) entity.Runner { return sensor.NewTopProcesses(m) },
+			Icon:   "mdi:format-list-numbered",
+		}
+	},
+	"systemd_units": func(_ entity.Meta) entity.SensorDefinition {
+		return entity.SensorDefinition{
+			Type:       "sensor",
+			Runner:     func(m entity.Meta) entity.Runner { return sensor.NewSystemdUnits(m) },
+			Icon:       "mdi:cog-outline",
+			StateClass: "measurement",
+		}
+	},
+	"screen_locked": func(_ entity.Meta) entity.SensorDefinition {
+		return entity.SensorDefinition{
+			Type:   "binary_sensor",
+			Runner: func(m entity.Meta) entity.Runner { return sensor.NewScreenLocked(m) },
+			Icon:   "mdi:monitor",
+		}
+	},
+	"lid": func(_ entity.Meta) entity.SensorDefinition {
+		return entity.SensorDefinition{
+			Type:        "binary_sensor",
+			Runner:      func(m entity.Meta) entity.Runner { return sensor.NewLid() },
+			DeviceClass: "opening",
+			Icon:        "mdi:laptop",
+		}
+	},
+	"user_idle": func(_ entity.Meta) entity.SensorDefinition {
+		return entity.SensorDefinition{
+			Type:   "binary_sensor",
+			Runner: func(m entity.Meta) entity.Runner { return sensor.NewUserIdle(m) },
+			Icon:   "mdi:account-clock",
+		}
+	},
+	"package_updates": func(_ entity.Meta) entity.SensorDefinition {
+		return entity.SensorDefinition{
+			Type:       "sensor",
+			Runner:     func(m entity.Meta) entity.Runner { return sensor.NewPackageUpdates(m) },
+			Icon:       "mdi:package-variant-closed-check",
+			StateClass: "measurement",
+		}
+	},
+	"containers": func(_ entity.Meta) entity.SensorDefinition {
+		return entity.SensorDefinition{
+			Type:       "sensor",
+			Runner:     func(m entity.Meta) entity.Runner { return sensor.NewContainers(m) },
+			Icon:       "mdi:docker",
+			StateClass: "measurement",
+		}
+	},
+	"reboot_required": func(_ entity.Meta) entity.SensorDefinition {
+		return entity.SensorDefinition{
+			Type:        "binary_sensor",
+			Runner:      func(m entity.Meta) entity.Runner { return sensor.NewRebootRequired() },
+			DeviceClass: "update",
+			Icon:        "mdi:restart",
+		}
+	},
+	"file": func(m entity.Meta) entity.SensorDefinition {
+		return valueSensorDefinition(m, "mdi:file-document-outline", func(m entity.Meta) entity.Runner { return sensor.NewFile(m) })
+	},
+	"cert_expiry": func(_ entity.Meta) entity.SensorDefinition {
+		return entity.SensorDefinition{
+			Type:        "sensor",
+			Runner:      func(m entity.Meta) entity.Runner { return sensor.NewCertExpiry(m) },
+			DeviceClass: "duration",
+			Icon:        "mdi:certificate",
+			StateClass:  "measurement",
+			Unit:        "d",
+		}
+	},
+	"rest": func(m entity.Meta) entity.SensorDefinition {
+		return valueSensorDefinition(m, "mdi:api", func(m entity.Meta) entity.Runner { return sensor.NewRest(m) })
+	},
+	"prometheus": func(m entity.Meta) entity.SensorDefinition {
+		return valueSensorDefinition(m, "mdi:chart-line", func(m entity.Meta) entity.Runner { return sensor.NewPrometheus(m) })
+	},
+	"dbus": func(m entity.Meta) entity.SensorDefinition {
+		return valueSensorDefinition(m, "mdi:bus", func(m entity.Meta) entity.Runner { return sensor.NewDBusProperty(m) })
+	},
+	"displays": func(_ entity.Meta) entity.SensorDefinition {
+		return entity.SensorDefinition{
+			Type:       "sensor",
+			Runner:     func(m entity.Meta) entity.Runner { return sensor.NewDisplays() },
+			Icon:       "mdi:monitor",
+			StateClass: "measurement",
+		}
+	},
 	"companion_running": func(_ entity.Meta) entity.SensorDefinition {
 		return entity.SensorDefinition{
 			Type:   "binary_sensor",
@@ -95,3 +295,20 @@ var sensorDefinitions = map[string]func(m entity.Meta) entity.SensorDefinition{
 		}
 	},
 }
+
+// valueSensorDefinition returns the definition of a sensor that reports arbitrary values,
+// like the file or rest sensor. unit, device_class, state_class and icon are read from
+// the meta section, as they depend on the reported value.
+func valueSensorDefinition(m entity.Meta, icon string, runner func(m entity.Meta) entity.Runner) entity.SensorDefinition {
+	if custom := m.GetString("icon"); custom != "" {
+		icon = custom
+	}
+	return entity.SensorDefinition{
+		Type:        "sensor",
+		Runner:      runner,
+		DeviceClass: m.GetString("device_class"),
+		Icon:        icon,
+		StateClass:  m.GetString("state_class"),
+		Unit:        m.GetString("unit"),
+	}
+}
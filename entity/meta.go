@@ -21,9 +21,84 @@ func (m Meta) GetString(key string) string {
 
 func (m Meta) GetStringSlice(key string) []string {
 	if v, ok := m[key]; ok {
-		if value, isSlice := v.([]string); isSlice {
+		switch value := v.(type) {
+		case []string:
 			return value
+		case []interface{}:
+			// Arrays from the config file are decoded as []interface{}.
+			values := make([]string, 0, len(value))
+			for _, item := range value {
+				if s, isString := item.(string); isString {
+					values = append(values, s)
+				}
+			}
+			return values
 		}
 	}
 	return []string{}
 }
+
+func (m Meta) GetInt(key string) int {
+	if v, ok := m[key]; ok {
+		switch value := v.(type) {
+		case int:
+			return value
+		case int64:
+			return int(value)
+		case float64:
+			return int(value)
+		}
+	}
+	return 0
+}
+
+func (m Meta) GetFloat(key string) float64 {
+	if v, ok := m[key]; ok {
+		switch value := v.(type) {
+		case float64:
+			return value
+		case int:
+			return float64(value)
+		case int64:
+			return float64(value)
+		}
+	}
+	return 0
+}
+
+func (m Meta) GetIntSlice(key string) []int {
+	values := make([]int, 0)
+	if v, ok := m[key]; ok {
+		items, isSlice := v.([]interface{})
+		if !isSlice {
+			return values
+		}
+		// Numbers from the config file are decoded as int64.
+		for _, item := range items {
+			switch value := item.(type) {
+			case int:
+				values = append(values, value)
+			case int64:
+				values = append(values, int(value))
+			case float64:
+				values = append(values, int(value))
+			}
+		}
+	}
+	return values
+}
+
+func (m Meta) GetStringMap(key string) map[string]string {
+	values := make(map[string]string)
+	if v, ok := m[key]; ok {
+		// Tables from the config file are decoded as map[string]interface{}.
+		if table, isMap := v.(map[string]interface{}); isMap {
+			for k, item := range table {
+				if s, isString := item.(string); isString {
+					values[k] = s
+				}
+			}
+		}
+	}
+	return values
+}
@@ -12,6 +12,20 @@ type Runner interface {
 	Run(ctx context.Context) (*Payload, error)
 }
 
+// Watcher is implemented by Runners that are able to detect changes on their own.
+// Watch blocks until the context is canceled and calls notify whenever the
+// sensor should be updated outside of the regular update interval.
+type Watcher interface {
+	Watch(ctx context.Context, notify func()) error
+}
+
+// CommandHandler is implemented by Runners that act on commands sent from
+// Home Assistant as notifications, e.g. "command_media". HandleCommand
+// returns false if the Runner does not support the given command.
+type CommandHandler interface {
+	HandleCommand(ctx context.Context, command string, data map[string]interface{}) (bool, error)
+}
+
 // SensorDefinition contains all Home Assistant attributes.
 type SensorDefinition struct {
 	Type        string
@@ -23,9 +37,13 @@ type SensorDefinition struct {
 }
 
 // SensorConfig contains the configuration for a single sensor.
+// Kind selects the sensor definition to use. If it is empty, the
+// sensor's key in the config file is used instead. This allows a
+// sensor kind to be configured multiple times under different keys.
 type SensorConfig struct {
 	Enabled bool
 	Name    string
+	Kind    string
 	Meta    map[string]interface{}
 }
 
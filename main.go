@@ -39,10 +39,7 @@ var (
 )
 
 const (
-	loginDestination     = "org.freedesktop.login1"
-	loginPath            = "/org/freedesktop/login1"
-	loginInterface       = "org.freedesktop.login1.Manager"
-	loginMethodInhibit   = loginInterface + ".Inhibit"
+	loginMethodInhibit   = sensor.LogindManagerInterface + ".Inhibit"
 	inhibitorMessage     = "Setting sensors to unavailable"
 	inhibitorMethodSleep = "sleep"
 	inhibitorModeDelay   = "delay"
@@ -213,7 +210,7 @@ func (k *Kernel) Run(appCtx context.Context, quiet bool) error {
 	}
 
 	// Start the notifications server.
-	k.notifications, err = NewNotificationServer(registration, k.config.Notifications.Listen)
+	k.notifications, err = NewNotificationServer(registration, k.config.Notifications.Listen, sensors)
 	if err != nil {
 		return err
 	}
@@ -280,9 +277,13 @@ func (k *Kernel) buildSensors(config *Config, quiet bool) ([]entity.Sensor, erro
 		if !sensorConfig.Enabled {
 			continue
 		}
-		definition, ok := sensorDefinitions[key]
+		kind := key
+		if sensorConfig.Kind != "" {
+			kind = sensorConfig.Kind
+		}
+		definition, ok := sensorDefinitions[kind]
 		if !ok {
-			return nil, fmt.Errorf("unknown sensor %s in config", key)
+			return nil, fmt.Errorf("unknown sensor %s in config", kind)
 		}
 		data := definition(sensorConfig.Meta)
 		sensors = append(sensors, entity.Sensor{
@@ -349,6 +350,7 @@ func (k *Kernel) registerDevice(ctx context.Context) (api.Registration, error) {
 		log.Println("Push notifications will not work with your current config")
 	}
 
+	manufacturer, model := deviceModel()
 	registration, err := k.api.RegisterDevice(ctx, api.RegisterDeviceRequest{
 		AppData: api.AppData{
 			PushToken: token,
@@ -359,8 +361,8 @@ func (k *Kernel) registerDevice(ctx context.Context) (api.Registration, error) {
 		AppVersion:         Version,
 		DeviceID:           id,
 		DeviceName:         k.api.DeviceName,
-		Manufacturer:       Manufacturer,
-		Model:              Model,
+		Manufacturer:       manufacturer,
+		Model:              model,
 		OsVersion:          OsVersion,
 		SupportsEncryption: false,
 	})
@@ -390,6 +392,7 @@ func (k *Kernel) updateRegistration(ctx context.Context, registration api.Regist
 	if err != nil {
 		log.Println("Push notifications will not work with your current config")
 	}
+	manufacturer, model := deviceModel()
 	err = k.api.UpdateRegistration(ctx, api.UpdateRegistrationRequest{
 		AppData: api.AppData{
 			PushToken: registration.PushToken,
@@ -397,13 +400,26 @@ func (k *Kernel) updateRegistration(ctx context.Context, registration api.Regist
 		},
 		AppVersion:   Version,
 		DeviceName:   k.api.DeviceName,
-		Manufacturer: Manufacturer,
-		Model:        Model,
+		Manufacturer: manufacturer,
+		Model:        model,
 		OsVersion:    OsVersion,
 	})
 	return err
 }
 
+// deviceModel returns the manufacturer and model of this machine.
+// The companion's own values are used if the hardware is unknown.
+func deviceModel() (manufacturer, model string) {
+	manufacturer, model = sensor.DeviceModel()
+	if manufacturer == "" {
+		manufacturer = Manufacturer
+	}
+	if model == "" {
+		model = Model
+	}
+	return manufacturer, model
+}
+
 // NullRunner is a Runner that does not do anything.
 type NullRunner struct{}
 
@@ -430,8 +446,8 @@ func handleSleep(companion *Companion) {
 
 	var fd int
 	obj := conn.Object(
-		loginDestination,
-		dbus.ObjectPath(loginPath),
+		sensor.LogindDestination,
+		dbus.ObjectPath(sensor.LogindPath),
 	)
 
 	err = obj.Call(loginMethodInhibit, 0,
@@ -443,8 +459,8 @@ func handleSleep(companion *Companion) {
 	}
 
 	err = conn.AddMatchSignal(
-		dbus.WithMatchInterface(loginInterface),
-		dbus.WithMatchObjectPath(loginPath),
+		dbus.WithMatchInterface(sensor.LogindManagerInterface),
+		dbus.WithMatchObjectPath(sensor.LogindPath),
 		dbus.WithMatchMember("PrepareForSleep"),
 	)
 
@@ -5,6 +5,7 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net/http"
 	"os/exec"
@@ -15,6 +16,7 @@ import (
 	"time"
 
 	"hacompanion/api"
+	"hacompanion/entity"
 	"hacompanion/util"
 )
 
@@ -25,14 +27,21 @@ type NotificationServer struct {
 	address      string
 	Server       *http.Server
 	uid          string
+	handlers     []entity.CommandHandler
 }
 
-func NewNotificationServer(registration api.Registration, address string) (s *NotificationServer, err error) {
+func NewNotificationServer(registration api.Registration, address string, sensors []entity.Sensor) (s *NotificationServer, err error) {
 	s = &NotificationServer{
 		registration: registration,
 		mux:          http.NewServeMux(),
 		address:      address,
 	}
+	// Sensors can act on commands that are sent as notifications.
+	for _, sensor := range sensors {
+		if handler, ok := sensor.Runner.(entity.CommandHandler); ok {
+			s.handlers = append(s.handlers, handler)
+		}
+	}
 	s.Server = &http.Server{
 		Addr:    s.address,
 		Handler: s.mux,
@@ -58,7 +67,12 @@ func (s NotificationServer) Listen(_ context.Context) {
 		var notification Notification
 		var req api.PushNotificationRequest
 		w.Header().Set("Content-Type", "application/json")
-		err := json.NewDecoder(r.Body).Decode(&req)
+		body, err := io.ReadAll(r.Body)
+		if err != nil {
+			util.RespondError(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		err = json.Unmarshal(body, &req)
 		if err != nil {
 			util.RespondError(w, err.Error(), http.StatusBadRequest)
 			return
@@ -69,6 +83,25 @@ func (s NotificationServer) Listen(_ context.Context) {
 			util.RespondError(w, "wrong token", http.StatusUnauthorized)
 			return
 		}
+		if command := strings.ToLower(req.Message); strings.HasPrefix(command, "command_") {
+			// Commands can contain arbitrary data, so the data is decoded again without a fixed structure.
+			var raw struct {
+				Data map[string]interface{} `json:"data"`
+			}
+			if err = json.Unmarshal(body, &raw); err != nil {
+				util.RespondError(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err = s.handleCommand(r.Context(), command, raw.Data); err != nil {
+				log.Printf("failed to handle command %s: %s", command, err)
+				util.RespondError(w, err.Error(), http.StatusInternalServerError)
+				return
+			}
+			log.Printf("command %s handled successfully", command)
+			w.WriteHeader(http.StatusCreated)
+			util.RespondSuccess(w)
+			return
+		}
 		err = notification.Send(r.Context(), req.Title, req.Message, req.Data, s.uid)
 		if err != nil {
 			log.Printf("failed to send notification: %s", err)
@@ -87,6 +120,20 @@ func (s NotificationServer) Listen(_ context.Context) {
 	}
 }
 
+// handleCommand passes a command to the first sensor that supports it.
+func (s NotificationServer) handleCommand(ctx context.Context, command string, data map[string]interface{}) error {
+	for _, handler := range s.handlers {
+		handled, err := handler.HandleCommand(ctx, command, data)
+		if err != nil {
+			return err
+		}
+		if handled {
+			return nil
+		}
+	}
+	return fmt.Errorf("no sensor supports the command %s", command)
+}
+
 // Notification is used to send notifications using native tools.
 type Notification struct{}
 
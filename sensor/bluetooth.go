@@ -0,0 +1,138 @@
+package sensor
+
+import (
+	"context"
+	"fmt"
+	"sort"
+
+	"hacompanion/entity"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	bluezDestination      = "org.bluez"
+	bluezAdapterInterface = "org.bluez.Adapter1"
+	bluezDeviceInterface  = "org.bluez.Device1"
+	bluezBatteryInterface = "org.bluez.Battery1"
+	dbusGetManagedObjects = "org.freedesktop.DBus.ObjectManager.GetManagedObjects"
+)
+
+// bluezObjects is the result of BlueZ's GetManagedObjects call.
+type bluezObjects map[dbus.ObjectPath]map[string]map[string]dbus.Variant
+
+// Bluetooth reports the Bluetooth adapters and connected devices from BlueZ.
+type Bluetooth struct {
+	conn *busConnection
+}
+
+func NewBluetooth() *Bluetooth {
+	return &Bluetooth{conn: newBusConnection(busSystem)}
+}
+
+func (b *Bluetooth) Run(ctx context.Context) (*entity.Payload, error) {
+	conn, err := b.conn.get()
+	if err != nil {
+		return nil, err
+	}
+	var objects bluezObjects
+	err = conn.Object(bluezDestination, "/").CallWithContext(ctx, dbusGetManagedObjects, 0).Store(&objects)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get objects from BlueZ: %w", err)
+	}
+	return b.process(objects), nil
+}
+
+func (b *Bluetooth) process(objects bluezObjects) *entity.Payload {
+	paths := make([]string, 0, len(objects))
+	for path := range objects {
+		paths = append(paths, string(path))
+	}
+	// Sort the objects to get a stable order of adapters and devices.
+	sort.Strings(paths)
+
+	var powered bool
+	adapters := make([]string, 0)
+	devices := make([]map[string]interface{}, 0)
+	for _, path := range paths {
+		interfaces := objects[dbus.ObjectPath(path)]
+		if adapter, ok := interfaces[bluezAdapterInterface]; ok {
+			if name, ok := adapter["Alias"].Value().(string); ok {
+				adapters = append(adapters, name)
+			}
+			if on, ok := adapter["Powered"].Value().(bool); ok && on {
+				powered = true
+			}
+		}
+		device, ok := interfaces[bluezDeviceInterface]
+		if !ok {
+			continue
+		}
+		if connected, ok := device["Connected"].Value().(bool); !ok || !connected {
+			continue
+		}
+		info := map[string]interface{}{}
+		if name, ok := device["Alias"].Value().(string); ok {
+			info["name"] = name
+		}
+		if address, ok := device["Address"].Value().(string); ok {
+			info["address"] = address
+		}
+		// The RSSI is only known while the adapter is discovering.
+		if rssi, ok := device["RSSI"].Value().(int16); ok {
+			info["rssi"] = rssi
+		}
+		if icon, ok := device["Icon"].Value().(string); ok {
+			info["type"] = icon
+		}
+		if battery, ok := interfaces[bluezBatteryInterface]; ok {
+			if percentage, ok := battery["Percentage"].Value().(byte); ok {
+				info["battery"] = int(percentage)
+			}
+		}
+		devices = append(devices, info)
+	}
+
+	p := entity.NewPayload()
+	p.State = len(devices)
+	p.Attributes["powered"] = powered
+	p.Attributes["adapters"] = adapters
+	p.Attributes["devices"] = devices
+	switch {
+	case !powered:
+		p.Icon = "mdi:bluetooth-off"
+	case len(devices) > 0:
+		p.Icon = "mdi:bluetooth-connect"
+	}
+	return p
+}
+
+// Watch pushes updates when a device connects or disconnects, its
+// battery level changes or an adapter is switched on or off.
+func (b *Bluetooth) Watch(ctx context.Context, notify func()) error {
+	conn, err := connectBus(busSystem)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	watched := map[string]string{
+		bluezAdapterInterface: "Powered",
+		bluezDeviceInterface:  "Connected",
+		bluezBatteryInterface: "Percentage",
+	}
+	return watchSignals(ctx, conn, func(sig *dbus.Signal) {
+		iface, changed, ok := changedProperties(sig)
+		if !ok {
+			return
+		}
+		if property, ok := watched[iface]; ok {
+			if _, ok := changed[property]; ok {
+				notify()
+			}
+		}
+	},
+		dbus.WithMatchSender(bluezDestination),
+		dbus.WithMatchInterface(dbusPropertiesInterface),
+		dbus.WithMatchMember(dbusPropertiesChanged),
+	)
+}
@@ -0,0 +1,59 @@
+package sensor
+
+import (
+	"testing"
+
+	"hacompanion/entity"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestBluetooth(t *testing.T) {
+	objects := bluezObjects{
+		"/org/bluez/hci0": {
+			"org.bluez.Adapter1": {
+				"Alias":   dbus.MakeVariant("workstation"),
+				"Powered": dbus.MakeVariant(true),
+			},
+		},
+		"/org/bluez/hci0/dev_AA_BB_CC_DD_EE_FF": {
+			"org.bluez.Device1": {
+				"Alias":     dbus.MakeVariant("WH-1000XM4"),
+				"Address":   dbus.MakeVariant("AA:BB:CC:DD:EE:FF"),
+				"Icon":      dbus.MakeVariant("audio-headset"),
+				"Connected": dbus.MakeVariant(true),
+				"RSSI":      dbus.MakeVariant(int16(-52)),
+			},
+			"org.bluez.Battery1": {
+				"Percentage": dbus.MakeVariant(byte(70)),
+			},
+		},
+		"/org/bluez/hci0/dev_11_22_33_44_55_66": {
+			"org.bluez.Device1": {
+				"Alias":     dbus.MakeVariant("Keyboard"),
+				"Address":   dbus.MakeVariant("11:22:33:44:55:66"),
+				"Connected": dbus.MakeVariant(false),
+			},
+		},
+	}
+	output := &entity.Payload{
+		State: 1,
+		Icon:  "mdi:bluetooth-connect",
+		Attributes: map[string]interface{}{
+			"powered":  true,
+			"adapters": []string{"workstation"},
+			"devices": []map[string]interface{}{{
+				"name":    "WH-1000XM4",
+				"address": "AA:BB:CC:DD:EE:FF",
+				"type":    "audio-headset",
+				"rssi":    int16(-52),
+				"battery": 70,
+			}},
+		},
+	}
+
+	b := NewBluetooth()
+
+	require.EqualValues(t, output, b.process(objects))
+}
@@ -0,0 +1,201 @@
+package sensor
+
+import (
+	"context"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"errors"
+	"fmt"
+	"math"
+	"net"
+	"os"
+	"path/filepath"
+	"sort"
+	"time"
+
+	"hacompanion/entity"
+	"hacompanion/util"
+)
+
+// CertExpiry reports the number of days until the first of a set of certificates expires.
+// Certificates are read from PEM files or retrieved from a TLS server.
+type CertExpiry struct {
+	files []string
+	host  string
+	// serverName overrides the server name that is sent to the TLS server.
+	serverName string
+	now        func() time.Time
+}
+
+func NewCertExpiry(m entity.Meta) *CertExpiry {
+	files := m.GetStringSlice("files")
+	if file := m.GetString("file"); file != "" {
+		files = append(files, file)
+	}
+	return &CertExpiry{
+		files:      files,
+		host:       m.GetString("host"),
+		serverName: m.GetString("server_name"),
+		now:        time.Now,
+	}
+}
+
+// certificate is a certificate together with the source it was read from.
+type certificate struct {
+	source string
+	cert   *x509.Certificate
+}
+
+func (c CertExpiry) Run(ctx context.Context) (*entity.Payload, error) {
+	if len(c.files) == 0 && c.host == "" {
+		return nil, errors.New("cert_expiry requires files or a host to be configured")
+	}
+	var certs []certificate
+	for _, file := range c.files {
+		pattern, err := util.NewHomePath(file)
+		if err != nil {
+			return nil, err
+		}
+		matches, err := filepath.Glob(pattern.Path)
+		if err != nil {
+			return nil, err
+		}
+		if len(matches) == 0 {
+			return nil, fmt.Errorf("no certificate matches %s", file)
+		}
+		for _, path := range matches {
+			fileCerts, err := c.readFile(path)
+			if err != nil {
+				return nil, err
+			}
+			certs = append(certs, fileCerts...)
+		}
+	}
+	if c.host != "" {
+		cert, err := c.fetch(ctx)
+		if err != nil {
+			return nil, err
+		}
+		certs = append(certs, cert)
+	}
+	return c.process(certs), nil
+}
+
+// readFile returns all certificates in a PEM file, including intermediates of a chain.
+func (c CertExpiry) readFile(path string) ([]certificate, error) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var certs []certificate
+	for {
+		var block *pem.Block
+		block, b = pem.Decode(b)
+		if block == nil {
+			break
+		}
+		// Private keys may be stored in the same file.
+		if block.Type != "CERTIFICATE" {
+			continue
+		}
+		cert, err := x509.ParseCertificate(block.Bytes)
+		if err != nil {
+			return nil, fmt.Errorf("failed to parse certificate in %s: %w", path, err)
+		}
+		certs = append(certs, certificate{source: path, cert: cert})
+	}
+	if len(certs) == 0 {
+		return nil, fmt.Errorf("no certificate found in %s", path)
+	}
+	return certs, nil
+}
+
+// fetch returns the certificate of the configured TLS server.
+func (c CertExpiry) fetch(ctx context.Context) (certificate, error) {
+	serverName := c.serverName
+	if serverName == "" {
+		host, _, err := net.SplitHostPort(c.host)
+		if err != nil {
+			return certificate{}, err
+		}
+		serverName = host
+	}
+	dialer := tls.Dialer{
+		NetDialer: &net.Dialer{Timeout: 10 * time.Second},
+		Config: &tls.Config{
+			ServerName: serverName,
+			// The certificate is not verified, as expired or self-signed
+			// certificates are exactly what this sensor should report on.
+			InsecureSkipVerify: true, //nolint:gosec
+		},
+	}
+	conn, err := dialer.DialContext(ctx, "tcp", c.host)
+	if err != nil {
+		return certificate{}, fmt.Errorf("failed to connect to %s: %w", c.host, err)
+	}
+	defer conn.Close()
+	peers := conn.(*tls.Conn).ConnectionState().PeerCertificates
+	if len(peers) == 0 {
+		return certificate{}, fmt.Errorf("%s did not send a certificate", c.host)
+	}
+	return certificate{source: c.host, cert: peers[0]}, nil
+}
+
+func (c CertExpiry) process(certs []certificate) *entity.Payload {
+	// The certificate that expires first determines the state.
+	sort.SliceStable(certs, func(i, j int) bool {
+		return certs[i].cert.NotAfter.Before(certs[j].cert.NotAfter)
+	})
+	now := c.now()
+	all := make([]map[string]interface{}, 0, len(certs))
+	for _, cert := range certs {
+		all = append(all, map[string]interface{}{
+			"source":    cert.source,
+			"subject":   cert.cert.Subject.String(),
+			"not_after": cert.cert.NotAfter.UTC().Format(time.RFC3339),
+			"days":      c.days(cert.cert, now),
+		})
+	}
+
+	p := entity.NewPayload()
+	if len(certs) == 0 {
+		p.State = "unavailable"
+		return p
+	}
+	first := certs[0].cert
+	p.State = c.days(first, now)
+	p.Attributes["source"] = certs[0].source
+	p.Attributes["subject"] = first.Subject.String()
+	p.Attributes["issuer"] = first.Issuer.String()
+	p.Attributes["not_after"] = first.NotAfter.UTC().Format(time.RFC3339)
+	p.Attributes["sans"] = c.sans(first)
+	p.Attributes["expired"] = now.After(first.NotAfter)
+	if len(certs) > 1 {
+		p.Attributes["certificates"] = all
+	}
+	if now.After(first.NotAfter) {
+		p.Icon = "mdi:certificate-outline"
+	}
+	return p
+}
+
+// days returns the number of whole days until the certificate expires,
+// it is negative for expired certificates.
+func (c CertExpiry) days(cert *x509.Certificate, now time.Time) int {
+	return int(math.Floor(cert.NotAfter.Sub(now).Hours() / 24))
+}
+
+// sans returns the subject alternative names of a certificate.
+func (c CertExpiry) sans(cert *x509.Certificate) []string {
+	sans := make([]string, 0, len(cert.DNSNames)+len(cert.IPAddresses)+len(cert.EmailAddresses)+len(cert.URIs))
+	sans = append(sans, cert.DNSNames...)
+	for _, ip := range cert.IPAddresses {
+		sans = append(sans, ip.String())
+	}
+	sans = append(sans, cert.EmailAddresses...)
+	for _, uri := range cert.URIs {
+		sans = append(sans, uri.String())
+	}
+	return sans
+}
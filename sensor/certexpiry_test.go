@@ -0,0 +1,96 @@
+package sensor
+
+import (
+	"context"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/x509"
+	"crypto/x509/pkix"
+	"encoding/pem"
+	"math/big"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"hacompanion/entity"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeTestCertificate creates a self-signed certificate that expires at notAfter.
+func writeTestCertificate(t *testing.T, path, name string, notAfter time.Time) {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	require.NoError(t, err)
+	template := &x509.Certificate{
+		SerialNumber: big.NewInt(1),
+		Subject:      pkix.Name{CommonName: name},
+		NotBefore:    notAfter.Add(-365 * 24 * time.Hour),
+		NotAfter:     notAfter,
+		DNSNames:     []string{name},
+		IPAddresses:  []net.IP{net.ParseIP("127.0.0.1")},
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, template, &key.PublicKey, key)
+	require.NoError(t, err)
+	keyDER, err := x509.MarshalECPrivateKey(key)
+	require.NoError(t, err)
+	content := pem.EncodeToMemory(&pem.Block{Type: "EC PRIVATE KEY", Bytes: keyDER})
+	content = append(content, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})...)
+	require.NoError(t, os.WriteFile(path, content, 0o600))
+}
+
+func TestCertExpiryFiles(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	dir := t.TempDir()
+	writeTestCertificate(t, filepath.Join(dir, "ca.pem"), "Local CA", now.Add(400*24*time.Hour))
+	writeTestCertificate(t, filepath.Join(dir, "client.pem"), "client.local", now.Add(30*24*time.Hour+time.Hour))
+
+	c := NewCertExpiry(entity.Meta{"files": []interface{}{filepath.Join(dir, "*.pem")}})
+	c.now = func() time.Time { return now }
+	p, err := c.Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 30, p.State)
+	require.Equal(t, filepath.Join(dir, "client.pem"), p.Attributes["source"])
+	require.Equal(t, "CN=client.local", p.Attributes["subject"])
+	require.Equal(t, "CN=client.local", p.Attributes["issuer"])
+	require.Equal(t, "2024-07-01T13:00:00Z", p.Attributes["not_after"])
+	require.Equal(t, []string{"client.local", "127.0.0.1"}, p.Attributes["sans"])
+	require.Equal(t, false, p.Attributes["expired"])
+	require.Len(t, p.Attributes["certificates"], 2)
+
+	// Expired certificates report negative days.
+	c.now = func() time.Time { return now.Add(32 * 24 * time.Hour) }
+	p, err = c.Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, -2, p.State)
+	require.Equal(t, true, p.Attributes["expired"])
+}
+
+func TestCertExpiryHost(t *testing.T) {
+	server := httptest.NewTLSServer(http.HandlerFunc(func(http.ResponseWriter, *http.Request) {}))
+	defer server.Close()
+
+	notAfter := server.Certificate().NotAfter
+	c := NewCertExpiry(entity.Meta{"host": server.Listener.Addr().String(), "server_name": "example.com"})
+	c.now = func() time.Time { return notAfter.Add(-10*24*time.Hour - time.Minute) }
+	p, err := c.Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 10, p.State)
+	require.Equal(t, server.Listener.Addr().String(), p.Attributes["source"])
+	require.Contains(t, p.Attributes["sans"], "example.com")
+	require.NotContains(t, p.Attributes, "certificates")
+}
+
+func TestCertExpiryErrors(t *testing.T) {
+	_, err := NewCertExpiry(entity.Meta{}).Run(context.Background())
+	require.Error(t, err)
+	path := filepath.Join(t.TempDir(), "empty.pem")
+	require.NoError(t, os.WriteFile(path, []byte("not a certificate"), 0o600))
+	_, err = NewCertExpiry(entity.Meta{"file": path}).Run(context.Background())
+	require.Error(t, err)
+}
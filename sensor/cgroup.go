@@ -0,0 +1,304 @@
+package sensor
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"hacompanion/entity"
+	"hacompanion/util"
+)
+
+const (
+	cgroupStateMemory = "memory"
+	cgroupStateCPU    = "cpu"
+)
+
+// Cgroup reports the resource usage and limits of a cgroup v2, e.g. a systemd slice,
+// a service or a container. Without a configured path, the companion's own cgroup is
+// used. Inside a container, this reports the limits of the container.
+type Cgroup struct {
+	root     string
+	procRoot string
+	fsRoot   string
+	path     string
+	state    string
+	now      func() time.Time
+
+	mu sync.Mutex
+	// previousUsage is the CPU time in µs of the last run to calculate the CPU usage.
+	previousUsage uint64
+	previousAt    time.Time
+	previousPath  string
+}
+
+func NewCgroup(m entity.Meta) *Cgroup {
+	c := newCgroup(m.GetString("path"))
+	if m.GetString("state") == cgroupStateCPU {
+		c.state = cgroupStateCPU
+	}
+	return c
+}
+
+func newCgroup(path string) *Cgroup {
+	return &Cgroup{
+		root:     "/sys/fs/cgroup",
+		procRoot: "/proc",
+		fsRoot:   "/",
+		path:     path,
+		state:    cgroupStateMemory,
+		now:      time.Now,
+	}
+}
+
+// cgroupStats contains the values read from the cgroup interface files.
+type cgroupStats struct {
+	memoryCurrent   uint64
+	memoryMax       uint64
+	hasMemoryMax    bool
+	inactiveFile    uint64
+	swapCurrent     uint64
+	swapMax         uint64
+	hasSwapMax      bool
+	oomKills        uint64
+	cpuUsage        uint64
+	periods         uint64
+	throttled       uint64
+	throttledUsec   uint64
+	cpuQuota        float64
+	ioRead          uint64
+	ioWritten       uint64
+	pids            uint64
+	hasPids         bool
+	hasMemoryStats  bool
+	hasCPUStats     bool
+	hasIOStats      bool
+	hasMemoryEvents bool
+}
+
+func (c *Cgroup) Run(ctx context.Context) (*entity.Payload, error) {
+	path, err := c.resolve()
+	if err != nil {
+		return nil, err
+	}
+	dir := filepath.Join(c.root, path)
+	if _, err = os.Stat(filepath.Join(dir, "cgroup.controllers")); err != nil {
+		return nil, fmt.Errorf("cgroup %s not found, only cgroup v2 is supported: %w", path, err)
+	}
+	return c.process(path, c.read(dir), c.now()), nil
+}
+
+// inContainer returns true if the companion runs inside a container.
+func (c *Cgroup) inContainer() bool {
+	// Docker and Podman create these files in the root of the container.
+	for _, marker := range []string{".dockerenv", "run/.containerenv"} {
+		if _, err := os.Stat(filepath.Join(c.fsRoot, marker)); err == nil {
+			return true
+		}
+	}
+	// With its own cgroup namespace, the cgroup of the companion is the root of the namespace.
+	path, err := c.resolve()
+	return err == nil && path == "/"
+}
+
+// memoryLimit returns the path and the values of the nearest cgroup with a memory limit,
+// starting at the configured cgroup and moving up to the root, e.g. the limit of a container.
+func (c *Cgroup) memoryLimit() (string, cgroupStats, bool) {
+	path, err := c.resolve()
+	if err != nil {
+		return "", cgroupStats{}, false
+	}
+	for {
+		stats := c.read(filepath.Join(c.root, path))
+		if stats.hasMemoryMax && stats.hasMemoryStats {
+			return path, stats, true
+		}
+		if path == "/" || path == "." {
+			return "", cgroupStats{}, false
+		}
+		path = filepath.Dir(path)
+	}
+}
+
+// resolve returns the configured cgroup path, or the cgroup of the companion itself.
+func (c *Cgroup) resolve() (string, error) {
+	if c.path != "" {
+		return filepath.Join("/", c.path), nil
+	}
+	b, err := os.ReadFile(filepath.Join(c.procRoot, "self", "cgroup"))
+	if err != nil {
+		return "", err
+	}
+	// The cgroup v2 hierarchy is listed as "0::/path". Inside a container
+	// with its own cgroup namespace, this is "0::/".
+	scanner := bufio.NewScanner(strings.NewReader(string(b)))
+	for scanner.Scan() {
+		if path, ok := strings.CutPrefix(scanner.Text(), "0::"); ok {
+			return path, nil
+		}
+	}
+	return "", errors.New("the companion does not run in a cgroup v2 hierarchy")
+}
+
+func (c *Cgroup) read(dir string) cgroupStats {
+	var stats cgroupStats
+	read := func(name string) (string, bool) {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return "", false
+		}
+		return strings.TrimSpace(string(b)), true
+	}
+	// keyValues parses files with one "key value" pair per line.
+	keyValues := func(name string) (map[string]uint64, bool) {
+		content, ok := read(name)
+		if !ok {
+			return nil, false
+		}
+		values := make(map[string]uint64)
+		for _, line := range strings.Split(content, "\n") {
+			fields := strings.Fields(line)
+			if len(fields) != 2 {
+				continue
+			}
+			if value, err := strconv.ParseUint(fields[1], 10, 64); err == nil {
+				values[fields[0]] = value
+			}
+		}
+		return values, true
+	}
+
+	if current, ok := read("memory.current"); ok {
+		stats.memoryCurrent, _ = strconv.ParseUint(current, 10, 64)
+		stats.hasMemoryStats = true
+	}
+	if limit, ok := read("memory.max"); ok && limit != "max" {
+		stats.memoryMax, _ = strconv.ParseUint(limit, 10, 64)
+		stats.hasMemoryMax = stats.memoryMax > 0
+	}
+	if memory, ok := keyValues("memory.stat"); ok {
+		stats.inactiveFile = memory["inactive_file"]
+	}
+	if swap, ok := read("memory.swap.current"); ok {
+		stats.swapCurrent, _ = strconv.ParseUint(swap, 10, 64)
+	}
+	if limit, ok := read("memory.swap.max"); ok && limit != "max" {
+		stats.swapMax, _ = strconv.ParseUint(limit, 10, 64)
+		stats.hasSwapMax = true
+	}
+	if events, ok := keyValues("memory.events"); ok {
+		stats.oomKills = events["oom_kill"]
+		stats.hasMemoryEvents = true
+	}
+	if cpu, ok := keyValues("cpu.stat"); ok {
+		stats.cpuUsage = cpu["usage_usec"]
+		stats.periods = cpu["nr_periods"]
+		stats.throttled = cpu["nr_throttled"]
+		stats.throttledUsec = cpu["throttled_usec"]
+		stats.hasCPUStats = true
+	}
+	// cpu.max contains the quota and the period, e.g. "200000 100000" for two cores.
+	if limit, ok := read("cpu.max"); ok {
+		fields := strings.Fields(limit)
+		if len(fields) == 2 && fields[0] != "max" {
+			quota, quotaErr := strconv.ParseFloat(fields[0], 64)
+			period, periodErr := strconv.ParseFloat(fields[1], 64)
+			if quotaErr == nil && periodErr == nil && period > 0 {
+				stats.cpuQuota = quota / period
+			}
+		}
+	}
+	// io.stat contains a line per device, e.g. "259:0 rbytes=4096 wbytes=0 rios=1 ...".
+	if io, ok := read("io.stat"); ok {
+		stats.hasIOStats = true
+		for _, line := range strings.Split(io, "\n") {
+			for _, field := range strings.Fields(line) {
+				key, value, ok := strings.Cut(field, "=")
+				if !ok {
+					continue
+				}
+				n, err := strconv.ParseUint(value, 10, 64)
+				if err != nil {
+					continue
+				}
+				switch key {
+				case "rbytes":
+					stats.ioRead += n
+				case "wbytes":
+					stats.ioWritten += n
+				}
+			}
+		}
+	}
+	if pids, ok := read("pids.current"); ok {
+		stats.pids, _ = strconv.ParseUint(pids, 10, 64)
+		stats.hasPids = true
+	}
+	return stats
+}
+
+func (c *Cgroup) process(path string, stats cgroupStats, now time.Time) *entity.Payload {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	mb := func(bytes uint64) float64 {
+		return util.RoundToTwoDecimals(float64(bytes) / 1024 / 1024)
+	}
+	p := entity.NewPayload()
+	p.Attributes["path"] = path
+
+	if stats.hasMemoryStats {
+		p.Attributes["memory_usage"] = mb(stats.memoryCurrent)
+	}
+	if stats.hasMemoryMax {
+		p.Attributes["memory_limit"] = mb(stats.memoryMax)
+		p.Attributes["memory_percent"] = util.RoundToTwoDecimals(float64(stats.memoryCurrent) / float64(stats.memoryMax) * 100)
+	}
+	if stats.hasMemoryEvents {
+		p.Attributes["oom_kills"] = stats.oomKills
+	}
+
+	// The CPU usage is reported in percent of a single core, like the process sensor.
+	cpuKnown := false
+	if stats.hasCPUStats {
+		elapsed := now.Sub(c.previousAt).Microseconds()
+		if c.previousPath == path && !c.previousAt.IsZero() && elapsed > 0 && stats.cpuUsage >= c.previousUsage {
+			usage := util.RoundToTwoDecimals(float64(stats.cpuUsage-c.previousUsage) / float64(elapsed) * 100)
+			p.Attributes["cpu_usage"] = usage
+			cpuKnown = true
+		}
+		p.Attributes["cpu_periods"] = stats.periods
+		p.Attributes["cpu_throttled_periods"] = stats.throttled
+		p.Attributes["cpu_throttled_time"] = util.RoundToTwoDecimals(float64(stats.throttledUsec) / 1e6)
+		c.previousUsage = stats.cpuUsage
+		c.previousAt = now
+		c.previousPath = path
+	}
+	if stats.cpuQuota > 0 {
+		p.Attributes["cpu_limit"] = util.RoundToTwoDecimals(stats.cpuQuota)
+	}
+	if stats.hasIOStats {
+		p.Attributes["io_read"] = mb(stats.ioRead)
+		p.Attributes["io_written"] = mb(stats.ioWritten)
+	}
+	if stats.hasPids {
+		p.Attributes["processes"] = stats.pids
+	}
+
+	switch {
+	case c.state == cgroupStateCPU && cpuKnown:
+		p.State = p.Attributes["cpu_usage"]
+	case c.state == cgroupStateMemory && stats.hasMemoryStats:
+		p.State = p.Attributes["memory_usage"]
+	default:
+		p.State = "unavailable"
+	}
+	return p
+}
@@ -0,0 +1,168 @@
+package sensor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"hacompanion/entity"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writeCgroup(t *testing.T, dir string, files map[string]string) {
+	t.Helper()
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	for name, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content), 0o600))
+	}
+}
+
+func TestCgroup(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "system.slice", "docker-1234.scope")
+	files := map[string]string{
+		"cgroup.controllers": "cpuset cpu io memory pids\n",
+		"memory.current":     "536870912\n",
+		"memory.max":         "2147483648\n",
+		"memory.events":      "low 0\nhigh 0\nmax 12\noom 1\noom_kill 1\noom_group_kill 0\n",
+		"cpu.stat":           "usage_usec 1000000\nuser_usec 800000\nsystem_usec 200000\nnr_periods 500\nnr_throttled 25\nthrottled_usec 1500000\n",
+		"cpu.max":            "150000 100000\n",
+		"io.stat":            "259:0 rbytes=1048576 wbytes=2097152 rios=10 wios=20 dbytes=0 dios=0\n8:0 rbytes=1048576 wbytes=0 rios=1 wios=0 dbytes=0 dios=0\n",
+		"pids.current":       "12\n",
+	}
+	writeCgroup(t, dir, files)
+
+	c := NewCgroup(entity.Meta{"path": "system.slice/docker-1234.scope", "state": "cpu"})
+	c.root = root
+	start := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	path, err := c.resolve()
+	require.NoError(t, err)
+
+	p := c.process(path, c.read(dir), start)
+	require.Equal(t, "unavailable", p.State)
+	require.NotContains(t, p.Attributes, "cpu_usage")
+
+	files["cpu.stat"] = "usage_usec 3500000\nnr_periods 520\nnr_throttled 30\nthrottled_usec 2000000\n"
+	writeCgroup(t, dir, files)
+	p = c.process(path, c.read(dir), start.Add(2*time.Second))
+	require.Equal(t, 125.0, p.State)
+	require.EqualValues(t, map[string]interface{}{
+		"path":                  "/system.slice/docker-1234.scope",
+		"memory_usage":          512.0,
+		"memory_limit":          2048.0,
+		"memory_percent":        25.0,
+		"oom_kills":             uint64(1),
+		"cpu_usage":             125.0,
+		"cpu_periods":           uint64(520),
+		"cpu_throttled_periods": uint64(30),
+		"cpu_throttled_time":    2.0,
+		"cpu_limit":             1.5,
+		"io_read":               2.0,
+		"io_written":            2.0,
+		"processes":             uint64(12),
+	}, p.Attributes)
+}
+
+func TestCgroupOwn(t *testing.T) {
+	root := t.TempDir()
+	proc := t.TempDir()
+	// Inside a container, the own cgroup is the root of the cgroup namespace.
+	writeCgroup(t, filepath.Join(proc, "self"), map[string]string{"cgroup": "0::/\n"})
+	writeCgroup(t, root, map[string]string{
+		"cgroup.controllers": "cpu memory\n",
+		"memory.current":     "104857600\n",
+		"memory.max":         "max\n",
+	})
+
+	c := NewCgroup(entity.Meta{})
+	c.root, c.procRoot = root, proc
+	p, err := c.Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 100.0, p.State)
+	require.EqualValues(t, map[string]interface{}{"path": "/", "memory_usage": 100.0}, p.Attributes)
+
+	// cgroup v1 hierarchies are not supported.
+	writeCgroup(t, filepath.Join(proc, "self"), map[string]string{"cgroup": "12:memory:/user.slice\n"})
+	_, err = c.Run(context.Background())
+	require.Error(t, err)
+
+	c.path = "missing.slice"
+	_, err = c.Run(context.Background())
+	require.Error(t, err)
+}
+
+func TestCgroupCPUUsage(t *testing.T) {
+	root := t.TempDir()
+	dir := filepath.Join(root, "user.slice")
+	files := map[string]string{
+		"cgroup.controllers": "cpu memory\n",
+		"cpu.stat":           "usage_usec 1000000\nnr_periods 0\nnr_throttled 0\nthrottled_usec 0\n",
+	}
+	writeCgroup(t, dir, files)
+
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	c := NewCgroup(entity.Meta{"path": "user.slice", "state": "cpu"})
+	c.root = root
+	c.now = func() time.Time { return now }
+
+	// The first run only reads the CPU time, the usage is known from the second run on.
+	p, err := c.Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "unavailable", p.State)
+
+	now = now.Add(10 * time.Second)
+	files["cpu.stat"] = "usage_usec 6000000\nnr_periods 0\nnr_throttled 0\nthrottled_usec 0\n"
+	writeCgroup(t, dir, files)
+	p, err = c.Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 50.0, p.State)
+}
+
+func TestCgroupMemoryLimit(t *testing.T) {
+	root := t.TempDir()
+	proc := t.TempDir()
+	writeCgroup(t, filepath.Join(proc, "self"), map[string]string{"cgroup": "0::/system.slice/docker-1234.scope/init\n"})
+	// The limit is set on the container scope, not on the cgroup of the process itself.
+	writeCgroup(t, filepath.Join(root, "system.slice", "docker-1234.scope"), map[string]string{
+		"memory.current": "536870912\n",
+		"memory.max":     "1073741824\n",
+	})
+	writeCgroup(t, filepath.Join(root, "system.slice", "docker-1234.scope", "init"), map[string]string{
+		"memory.current": "1048576\n",
+		"memory.max":     "max\n",
+	})
+
+	c := newCgroup("")
+	c.root, c.procRoot = root, proc
+	path, stats, ok := c.memoryLimit()
+	require.True(t, ok)
+	require.Equal(t, "/system.slice/docker-1234.scope", path)
+	require.Equal(t, uint64(1073741824), stats.memoryMax)
+
+	// Without any limit, the memory of the host is reported.
+	writeCgroup(t, filepath.Join(proc, "self"), map[string]string{"cgroup": "0::/system.slice\n"})
+	_, _, ok = c.memoryLimit()
+	require.False(t, ok)
+}
+
+func TestCgroupInContainer(t *testing.T) {
+	fs := t.TempDir()
+	proc := t.TempDir()
+	c := newCgroup("")
+	c.fsRoot, c.procRoot = fs, proc
+
+	// A cgroup of the host, e.g. a user service, is not a container.
+	writeCgroup(t, filepath.Join(proc, "self"), map[string]string{"cgroup": "0::/user.slice/user-1000.slice/user@1000.service/app.slice/hacompanion.service\n"})
+	require.False(t, c.inContainer())
+
+	writeCgroup(t, filepath.Join(fs, "run"), map[string]string{".containerenv": ""})
+	require.True(t, c.inContainer())
+
+	// Containers with their own cgroup namespace are detected without a marker file.
+	require.NoError(t, os.Remove(filepath.Join(fs, "run", ".containerenv")))
+	writeCgroup(t, filepath.Join(proc, "self"), map[string]string{"cgroup": "0::/\n"})
+	require.True(t, c.inContainer())
+}
@@ -0,0 +1,186 @@
+package sensor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+
+	"hacompanion/entity"
+	"hacompanion/util"
+)
+
+// Containers reports the state of Docker or Podman containers.
+type Containers struct {
+	sockets    []string
+	containers []string
+}
+
+func NewContainers(m entity.Meta) *Containers {
+	c := &Containers{
+		containers: m.GetStringSlice("containers"),
+	}
+	if socket := m.GetString("socket"); socket != "" {
+		c.sockets = []string{socket}
+	} else {
+		c.sockets = containerSockets()
+	}
+	return c
+}
+
+// containerSockets returns the default API sockets of Docker and Podman.
+func containerSockets() []string {
+	sockets := []string{"/var/run/docker.sock"}
+	runtimeDir := os.Getenv("XDG_RUNTIME_DIR")
+	if runtimeDir == "" {
+		runtimeDir = fmt.Sprintf("/run/user/%d", os.Getuid())
+	}
+	return append(sockets,
+		filepath.Join(runtimeDir, "docker.sock"),
+		filepath.Join(runtimeDir, "podman", "podman.sock"),
+		"/run/podman/podman.sock",
+	)
+}
+
+// container is a single entry of the /containers/json API response,
+// which is supported by both Docker and Podman.
+type container struct {
+	Names  []string `json:"Names"`
+	State  string   `json:"State"`
+	Status string   `json:"Status"`
+}
+
+func (c Containers) Run(ctx context.Context) (*entity.Payload, error) {
+	var socket string
+	for _, path := range c.sockets {
+		if exists, _ := util.FileExists(path); exists {
+			socket = path
+			break
+		}
+	}
+	if socket == "" {
+		return nil, fmt.Errorf("no container API socket found in %s", strings.Join(c.sockets, ", "))
+	}
+	containers, err := c.list(ctx, socket)
+	if err != nil {
+		return nil, err
+	}
+	p := c.process(containers)
+	p.Attributes["socket"] = socket
+	return p, nil
+}
+
+// list requests all containers over the HTTP API on the given unix socket.
+func (c Containers) list(ctx context.Context, socket string) ([]container, error) {
+	client := http.Client{
+		Timeout: 5 * time.Second,
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, _, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, "unix", socket)
+			},
+		},
+	}
+	defer client.CloseIdleConnections()
+	// The host is ignored, all requests are sent to the socket.
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, "http://localhost/containers/json?all=true", nil)
+	if err != nil {
+		return nil, err
+	}
+	resp, err := client.Do(req)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list containers: %w", err)
+	}
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("failed to list containers, received status %d: %s", resp.StatusCode, body)
+	}
+	var containers []container
+	if err = json.Unmarshal(body, &containers); err != nil {
+		return nil, fmt.Errorf("failed to parse container list: %w", err)
+	}
+	return containers, nil
+}
+
+func (c Containers) process(containers []container) *entity.Payload {
+	var running, paused, stopped, unhealthy int
+	states := make(map[string]interface{})
+	for _, ctr := range containers {
+		health := c.health(ctr.Status)
+		switch ctr.State {
+		case "running":
+			running++
+		case "paused":
+			paused++
+		case "exited", "created", "dead", "stopped":
+			stopped++
+		}
+		if health == "unhealthy" {
+			unhealthy++
+		}
+		for _, name := range ctr.Names {
+			// Docker prefixes container names with a slash.
+			name = strings.TrimPrefix(name, "/")
+			if !slices.Contains(c.containers, name) {
+				continue
+			}
+			state := map[string]interface{}{
+				"state":  ctr.State,
+				"status": ctr.Status,
+			}
+			if health != "" {
+				state["health"] = health
+			}
+			states[name] = state
+		}
+	}
+	// Selected containers that don't exist are reported as missing.
+	for _, name := range c.containers {
+		if _, ok := states[name]; !ok {
+			states[name] = map[string]interface{}{"state": "missing"}
+		}
+	}
+
+	p := entity.NewPayload()
+	p.State = running
+	p.Attributes["running"] = running
+	p.Attributes["paused"] = paused
+	p.Attributes["stopped"] = stopped
+	p.Attributes["unhealthy"] = unhealthy
+	p.Attributes["total"] = len(containers)
+	if len(c.containers) > 0 {
+		p.Attributes["containers"] = states
+	}
+	if unhealthy > 0 {
+		p.Icon = "mdi:docker-alert"
+	}
+	return p
+}
+
+// health extracts the health state from a status like "Up 2 hours (healthy)".
+func (c Containers) health(status string) string {
+	start := strings.LastIndex(status, "(")
+	end := strings.LastIndex(status, ")")
+	if start < 0 || end < start {
+		return ""
+	}
+	// Other values in parentheses are exit codes like "Exited (0) 2 hours ago".
+	switch health := status[start+1 : end]; health {
+	case "healthy", "unhealthy":
+		return health
+	case "health: starting":
+		return "starting"
+	}
+	return ""
+}
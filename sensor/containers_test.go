@@ -0,0 +1,91 @@
+package sensor
+
+import (
+	"context"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+
+	"hacompanion/entity"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestContainers(t *testing.T) {
+	// Unix socket paths are limited in length, so t.TempDir() can't be used.
+	dir, err := os.MkdirTemp("", "hac")
+	require.NoError(t, err)
+	t.Cleanup(func() { os.RemoveAll(dir) })
+	socket := filepath.Join(dir, "docker.sock")
+
+	listener, err := net.Listen("unix", socket)
+	require.NoError(t, err)
+	// The request is checked in the test, as the handler runs in another goroutine.
+	var mu sync.Mutex
+	var path, all string
+	server := httptest.NewUnstartedServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		mu.Lock()
+		path, all = r.URL.Path, r.URL.Query().Get("all")
+		mu.Unlock()
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write([]byte(`[
+			{"Id": "a1", "Names": ["/postgres"], "State": "running", "Status": "Up 2 hours (healthy)"},
+			{"Id": "b2", "Names": ["/web"], "State": "running", "Status": "Up 5 minutes (unhealthy)"},
+			{"Id": "c3", "Names": ["/migrations"], "State": "exited", "Status": "Exited (0) 2 hours ago"},
+			{"Id": "d4", "Names": ["/redis"], "State": "running", "Status": "Up 2 hours"},
+			{"Id": "e5", "Names": ["/worker"], "State": "paused", "Status": "Up 3 hours (Paused)"}
+		]`))
+	}))
+	server.Listener = listener
+	server.Start()
+	t.Cleanup(server.Close)
+
+	output := &entity.Payload{
+		State: 3,
+		Icon:  "mdi:docker-alert",
+		Attributes: map[string]interface{}{
+			"running":   3,
+			"paused":    1,
+			"stopped":   1,
+			"unhealthy": 1,
+			"total":     5,
+			"socket":    socket,
+			"containers": map[string]interface{}{
+				"web": map[string]interface{}{
+					"state":  "running",
+					"status": "Up 5 minutes (unhealthy)",
+					"health": "unhealthy",
+				},
+				"migrations": map[string]interface{}{
+					"state":  "exited",
+					"status": "Exited (0) 2 hours ago",
+				},
+				"worker": map[string]interface{}{
+					"state":  "paused",
+					"status": "Up 3 hours (Paused)",
+				},
+				"frontend": map[string]interface{}{
+					"state": "missing",
+				},
+			},
+		},
+	}
+
+	c := NewContainers(entity.Meta{
+		"socket":     socket,
+		"containers": []interface{}{"web", "migrations", "worker", "frontend"},
+	})
+
+	res, err := c.Run(context.Background())
+	require.NoError(t, err)
+	require.EqualValues(t, output, res)
+
+	mu.Lock()
+	defer mu.Unlock()
+	require.Equal(t, "/containers/json", path)
+	require.Equal(t, "true", all)
+}
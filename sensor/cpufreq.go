@@ -0,0 +1,134 @@
+package sensor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"hacompanion/entity"
+	"hacompanion/util"
+)
+
+var reCPUDir = regexp.MustCompile(`^cpu(\d+)$`)
+
+// CPUFreq reports the current frequency and the scaling settings of all cores.
+type CPUFreq struct {
+	root string
+}
+
+func NewCPUFreq() *CPUFreq {
+	return &CPUFreq{root: "/sys/devices/system/cpu"}
+}
+
+// cpuCore contains the cpufreq values of a single core. sysfs
+// reports frequencies in kHz, they are converted to MHz.
+type cpuCore struct {
+	id          int
+	current     float64
+	min         float64
+	max         float64
+	hardwareMax float64
+	governor    string
+	preference  string
+}
+
+func (c CPUFreq) Run(ctx context.Context) (*entity.Payload, error) {
+	entries, err := os.ReadDir(c.root)
+	if err != nil {
+		return nil, err
+	}
+	var cores []cpuCore
+	for _, entry := range entries {
+		match := reCPUDir.FindStringSubmatch(entry.Name())
+		if match == nil {
+			continue
+		}
+		id, _ := strconv.Atoi(match[1])
+		core, ok := c.readCore(id, filepath.Join(c.root, entry.Name(), "cpufreq"))
+		if ok {
+			cores = append(cores, core)
+		}
+	}
+	if len(cores) == 0 {
+		return nil, fmt.Errorf("no cpufreq information found in %s", c.root)
+	}
+	return c.process(cores), nil
+}
+
+// readCore reads the cpufreq values of a core. Offline cores have no current frequency.
+func (c CPUFreq) readCore(id int, dir string) (cpuCore, bool) {
+	read := func(name string) string {
+		b, err := os.ReadFile(filepath.Join(dir, name))
+		if err != nil {
+			return ""
+		}
+		return strings.TrimSpace(string(b))
+	}
+	mhz := func(name string) float64 {
+		khz, err := strconv.ParseFloat(read(name), 64)
+		if err != nil {
+			return 0
+		}
+		return khz / 1000
+	}
+	core := cpuCore{
+		id:          id,
+		current:     mhz("scaling_cur_freq"),
+		min:         mhz("scaling_min_freq"),
+		max:         mhz("scaling_max_freq"),
+		hardwareMax: mhz("cpuinfo_max_freq"),
+		governor:    read("scaling_governor"),
+		preference:  read("energy_performance_preference"),
+	}
+	if core.current == 0 {
+		// The hardware frequency is only readable by root on most systems.
+		core.current = mhz("cpuinfo_cur_freq")
+	}
+	return core, core.current > 0
+}
+
+func (c CPUFreq) process(cores []cpuCore) *entity.Payload {
+	sort.Slice(cores, func(i, j int) bool { return cores[i].id < cores[j].id })
+
+	p := entity.NewPayload()
+	var total, minFreq, maxFreq, hardwareMax float64
+	var governors, preferences []string
+	for i, core := range cores {
+		total += core.current
+		p.Attributes[fmt.Sprintf("core_%d", core.id)] = util.RoundToTwoDecimals(core.current)
+		if i == 0 || core.min < minFreq {
+			minFreq = core.min
+		}
+		maxFreq = max(maxFreq, core.max)
+		hardwareMax = max(hardwareMax, core.hardwareMax)
+		if core.governor != "" {
+			governors = append(governors, core.governor)
+		}
+		if core.preference != "" {
+			preferences = append(preferences, core.preference)
+		}
+	}
+	p.State = util.RoundToTwoDecimals(total / float64(len(cores)))
+	if minFreq > 0 {
+		p.Attributes["min_frequency"] = util.RoundToTwoDecimals(minFreq)
+	}
+	if maxFreq > 0 {
+		p.Attributes["max_frequency"] = util.RoundToTwoDecimals(maxFreq)
+	}
+	if hardwareMax > 0 {
+		p.Attributes["hardware_max_frequency"] = util.RoundToTwoDecimals(hardwareMax)
+	}
+	// Cores usually share the same settings, different values are listed together.
+	if len(governors) > 0 {
+		p.Attributes["governor"] = strings.Join(uniqueSorted(governors), ", ")
+	}
+	if len(preferences) > 0 {
+		p.Attributes["energy_performance_preference"] = strings.Join(uniqueSorted(preferences), ", ")
+	}
+	return p
+}
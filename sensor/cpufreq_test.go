@@ -0,0 +1,48 @@
+package sensor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestCPUFreq(t *testing.T) {
+	root := t.TempDir()
+	cores := map[string]map[string]string{
+		"cpu0": {"scaling_cur_freq": "2400000", "scaling_min_freq": "400000", "scaling_max_freq": "4700000", "cpuinfo_max_freq": "4700000", "scaling_governor": "powersave", "energy_performance_preference": "balance_power"},
+		"cpu1": {"scaling_cur_freq": "1200500", "scaling_min_freq": "800000", "scaling_max_freq": "3500000", "cpuinfo_max_freq": "3500000", "scaling_governor": "powersave", "energy_performance_preference": "balance_performance"},
+		// Cores are sorted numerically.
+		"cpu10": {"scaling_cur_freq": "3000000", "scaling_min_freq": "400000", "scaling_max_freq": "4700000", "cpuinfo_max_freq": "4700000", "scaling_governor": "powersave", "energy_performance_preference": "balance_power"},
+		// Offline cores are ignored.
+		"cpu2": {"scaling_governor": "powersave"},
+	}
+	for core, files := range cores {
+		dir := filepath.Join(root, core, "cpufreq")
+		require.NoError(t, os.MkdirAll(dir, 0o755))
+		for name, content := range files {
+			require.NoError(t, os.WriteFile(filepath.Join(dir, name), []byte(content+"\n"), 0o600))
+		}
+	}
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "cpufreq"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "cpuidle"), 0o755))
+
+	p, err := CPUFreq{root: root}.Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2200.16, p.State)
+	require.EqualValues(t, map[string]interface{}{
+		"core_0":                        2400.0,
+		"core_1":                        1200.5,
+		"core_10":                       3000.0,
+		"min_frequency":                 400.0,
+		"max_frequency":                 4700.0,
+		"hardware_max_frequency":        4700.0,
+		"governor":                      "powersave",
+		"energy_performance_preference": "balance_performance, balance_power",
+	}, p.Attributes)
+
+	_, err = CPUFreq{root: t.TempDir()}.Run(context.Background())
+	require.Error(t, err)
+}
@@ -0,0 +1,105 @@
+package sensor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	busSystem  = "system"
+	busSession = "session"
+
+	dbusPropertiesInterface = "org.freedesktop.DBus.Properties"
+	dbusPropertiesChanged   = "PropertiesChanged"
+)
+
+// connectBus opens a new private connection to the system or session bus.
+func connectBus(bus string) (*dbus.Conn, error) {
+	switch bus {
+	case busSystem:
+		return dbus.ConnectSystemBus()
+	case busSession:
+		return dbus.ConnectSessionBus()
+	default:
+		return nil, fmt.Errorf("unknown D-Bus bus %s, expected %s or %s", bus, busSystem, busSession)
+	}
+}
+
+// busConnection lazily connects to a message bus and reconnects once the connection was lost.
+type busConnection struct {
+	bus  string
+	mu   sync.Mutex
+	conn *dbus.Conn
+}
+
+func newBusConnection(bus string) *busConnection {
+	return &busConnection{bus: bus}
+}
+
+func (b *busConnection) get() (*dbus.Conn, error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.conn != nil && b.conn.Connected() {
+		return b.conn, nil
+	}
+	conn, err := connectBus(b.bus)
+	if err != nil {
+		return nil, fmt.Errorf("failed to connect to the %s bus: %w", b.bus, err)
+	}
+	b.conn = conn
+	return conn, nil
+}
+
+// watchSignals calls notify for every signal that matches the given options,
+// until the context is canceled or the connection is lost. The connection
+// should not be used for other subscriptions, as all signals it receives
+// are passed to notify.
+func watchSignals(ctx context.Context, conn *dbus.Conn, notify func(*dbus.Signal), options ...dbus.MatchOption) error {
+	if err := conn.AddMatchSignalContext(ctx, options...); err != nil {
+		return fmt.Errorf("failed to add match signal: %w", err)
+	}
+	signals := make(chan *dbus.Signal, 10)
+	conn.Signal(signals)
+	defer conn.RemoveSignal(signals)
+	for {
+		select {
+		case <-ctx.Done():
+			return nil
+		case sig, ok := <-signals:
+			if !ok {
+				return errors.New("connection to D-Bus was closed")
+			}
+			notify(sig)
+		}
+	}
+}
+
+// changedProperties returns the changed properties of a PropertiesChanged signal.
+// Properties that were invalidated without a new value are included with a nil value.
+func changedProperties(sig *dbus.Signal) (iface string, changed map[string]interface{}, ok bool) {
+	if sig.Name != dbusPropertiesInterface+"."+dbusPropertiesChanged || len(sig.Body) < 3 {
+		return "", nil, false
+	}
+	iface, ok = sig.Body[0].(string)
+	if !ok {
+		return "", nil, false
+	}
+	variants, ok := sig.Body[1].(map[string]dbus.Variant)
+	if !ok {
+		return "", nil, false
+	}
+	changed = make(map[string]interface{}, len(variants))
+	for name, value := range variants {
+		changed[name] = value.Value()
+	}
+	if invalidated, isSlice := sig.Body[2].([]string); isSlice {
+		for _, name := range invalidated {
+			changed[name] = nil
+		}
+	}
+	return iface, changed, true
+}
@@ -0,0 +1,35 @@
+package sensor
+
+import (
+	"testing"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestChangedProperties(t *testing.T) {
+	sig := &dbus.Signal{
+		Name: "org.freedesktop.DBus.Properties.PropertiesChanged",
+		Path: "/org/freedesktop/systemd1/unit/syncthing_2eservice",
+		Body: []interface{}{
+			"org.freedesktop.systemd1.Unit",
+			map[string]dbus.Variant{
+				"ActiveState": dbus.MakeVariant("active"),
+				"SubState":    dbus.MakeVariant("running"),
+			},
+			[]string{"StateChangeTimestamp"},
+		},
+	}
+
+	iface, changed, ok := changedProperties(sig)
+	require.True(t, ok)
+	require.Equal(t, "org.freedesktop.systemd1.Unit", iface)
+	require.Equal(t, map[string]interface{}{
+		"ActiveState":          "active",
+		"SubState":             "running",
+		"StateChangeTimestamp": nil,
+	}, changed)
+
+	_, _, ok = changedProperties(&dbus.Signal{Name: "org.freedesktop.login1.Manager.PrepareForSleep", Body: []interface{}{true}})
+	require.False(t, ok)
+}
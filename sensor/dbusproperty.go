@@ -0,0 +1,170 @@
+package sensor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"reflect"
+	"slices"
+
+	"hacompanion/entity"
+	"hacompanion/util"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// DBusProperty reports the value of any D-Bus property, e.g. the active power profile.
+type DBusProperty struct {
+	bus         string
+	destination string
+	path        dbus.ObjectPath
+	iface       string
+	property    string
+	// attributes are additional properties of the same interface.
+	attributes []string
+	// mapping maps values to states, e.g. for enums that are exposed as numbers.
+	mapping map[string]string
+	conn    *busConnection
+}
+
+func NewDBusProperty(m entity.Meta) *DBusProperty {
+	d := &DBusProperty{
+		bus:         busSystem,
+		destination: m.GetString("destination"),
+		path:        dbus.ObjectPath(m.GetString("path")),
+		iface:       m.GetString("interface"),
+		property:    m.GetString("property"),
+		attributes:  m.GetStringSlice("attributes"),
+		mapping:     m.GetStringMap("map"),
+	}
+	if bus := m.GetString("bus"); bus != "" {
+		d.bus = bus
+	}
+	d.conn = newBusConnection(d.bus)
+	return d
+}
+
+func (d *DBusProperty) validate() error {
+	if d.destination == "" || d.iface == "" || d.property == "" {
+		return errors.New("dbus sensor requires destination, interface and property to be specified")
+	}
+	if !d.path.IsValid() {
+		return fmt.Errorf("dbus sensor has an invalid object path %q", d.path)
+	}
+	return nil
+}
+
+func (d *DBusProperty) Run(ctx context.Context) (*entity.Payload, error) {
+	if err := d.validate(); err != nil {
+		return nil, err
+	}
+	conn, err := d.conn.get()
+	if err != nil {
+		return nil, err
+	}
+	obj := conn.Object(d.destination, d.path)
+	var value dbus.Variant
+	err = obj.CallWithContext(ctx, dbusPropertiesInterface+".Get", 0, d.iface, d.property).Store(&value)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get %s.%s: %w", d.iface, d.property, err)
+	}
+	attributes := make(map[string]dbus.Variant, len(d.attributes))
+	for _, name := range d.attributes {
+		var attribute dbus.Variant
+		// Optional properties that are not available are left out.
+		if obj.CallWithContext(ctx, dbusPropertiesInterface+".Get", 0, d.iface, name).Store(&attribute) == nil {
+			attributes[name] = attribute
+		}
+	}
+	return d.process(value, attributes)
+}
+
+func (d *DBusProperty) process(value dbus.Variant, attributes map[string]dbus.Variant) (*entity.Payload, error) {
+	p := entity.NewPayload()
+	state := dbusValue(value.Value())
+	// key is the value as it is looked up in the mapping.
+	key := fmt.Sprint(state)
+	switch state.(type) {
+	case []interface{}, map[string]interface{}:
+		// Home Assistant only supports scalar states, complex values are reported
+		// as JSON and as attribute. The JSON representation is also used for the mapping.
+		b, err := json.Marshal(state)
+		if err != nil {
+			return nil, err
+		}
+		key = string(b)
+		p.State = key
+		p.Attributes["value"] = state
+	default:
+		p.State = state
+	}
+	if mapped, ok := d.mapping[key]; ok {
+		p.State = mapped
+		p.Attributes["raw_value"] = state
+	}
+	for name, attribute := range attributes {
+		p.Attributes[util.ToSnakeCase(name)] = dbusValue(attribute.Value())
+	}
+	return p, nil
+}
+
+// Watch pushes updates when the property or one of the attributes changes.
+func (d *DBusProperty) Watch(ctx context.Context, notify func()) error {
+	if err := d.validate(); err != nil {
+		return err
+	}
+	conn, err := connectBus(d.bus)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return watchSignals(ctx, conn, func(sig *dbus.Signal) {
+		iface, changed, ok := changedProperties(sig)
+		if !ok || iface != d.iface {
+			return
+		}
+		for name := range changed {
+			if name == d.property || slices.Contains(d.attributes, name) {
+				notify()
+				return
+			}
+		}
+	},
+		dbus.WithMatchSender(d.destination),
+		dbus.WithMatchObjectPath(d.path),
+		dbus.WithMatchInterface(dbusPropertiesInterface),
+		dbus.WithMatchMember(dbusPropertiesChanged),
+		dbus.WithMatchArg(0, d.iface),
+	)
+}
+
+// dbusValue converts D-Bus values to types that can be encoded as JSON.
+func dbusValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case dbus.Variant:
+		return dbusValue(v.Value())
+	case dbus.ObjectPath:
+		return string(v)
+	case dbus.Signature:
+		return v.String()
+	}
+	// Arrays and dicts of any type are converted to slices and maps, e.g. "as" or "a{sv}".
+	rv := reflect.ValueOf(value)
+	switch rv.Kind() {
+	case reflect.Slice, reflect.Array:
+		values := make([]interface{}, 0, rv.Len())
+		for i := 0; i < rv.Len(); i++ {
+			values = append(values, dbusValue(rv.Index(i).Interface()))
+		}
+		return values
+	case reflect.Map:
+		values := make(map[string]interface{}, rv.Len())
+		iter := rv.MapRange()
+		for iter.Next() {
+			values[fmt.Sprint(iter.Key().Interface())] = dbusValue(iter.Value().Interface())
+		}
+		return values
+	}
+	return value
+}
@@ -0,0 +1,64 @@
+package sensor
+
+import (
+	"testing"
+
+	"hacompanion/entity"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestDBusProperty(t *testing.T) {
+	d := NewDBusProperty(entity.Meta{
+		"destination": "org.freedesktop.NetworkManager",
+		"path":        "/org/freedesktop/NetworkManager",
+		"interface":   "org.freedesktop.NetworkManager",
+		"property":    "State",
+		"attributes":  []interface{}{"Connectivity", "ActiveConnections"},
+		"map":         map[string]interface{}{"20": "disconnected", "70": "connected"},
+	})
+	require.NoError(t, d.validate())
+	require.Equal(t, busSystem, d.bus)
+
+	p, err := d.process(dbus.MakeVariant(uint32(70)), map[string]dbus.Variant{
+		"Connectivity":      dbus.MakeVariant(uint32(4)),
+		"ActiveConnections": dbus.MakeVariant([]dbus.ObjectPath{"/org/freedesktop/NetworkManager/ActiveConnection/1"}),
+	})
+	require.NoError(t, err)
+	require.Equal(t, "connected", p.State)
+	require.EqualValues(t, map[string]interface{}{
+		"raw_value":          uint32(70),
+		"connectivity":       uint32(4),
+		"active_connections": []interface{}{"/org/freedesktop/NetworkManager/ActiveConnection/1"},
+	}, p.Attributes)
+
+	// Unmapped values are reported as they are.
+	p, err = d.process(dbus.MakeVariant(uint32(50)), nil)
+	require.NoError(t, err)
+	require.Equal(t, uint32(50), p.State)
+	require.Empty(t, p.Attributes)
+
+	// Complex values are reported as JSON.
+	p, err = d.process(dbus.MakeVariant(map[string]dbus.Variant{"Profile": dbus.MakeVariant("balanced")}), nil)
+	require.NoError(t, err)
+	require.Equal(t, `{"Profile":"balanced"}`, p.State)
+	require.Equal(t, map[string]interface{}{"Profile": "balanced"}, p.Attributes["value"])
+
+	// The JSON representation is used to map complex values.
+	d.mapping = map[string]string{`["performance","balanced","power-saver"]`: "all"}
+	p, err = d.process(dbus.MakeVariant([]string{"performance", "balanced", "power-saver"}), nil)
+	require.NoError(t, err)
+	require.Equal(t, "all", p.State)
+	require.Equal(t, []interface{}{"performance", "balanced", "power-saver"}, p.Attributes["raw_value"])
+}
+
+func TestDBusPropertyValidate(t *testing.T) {
+	require.Error(t, NewDBusProperty(entity.Meta{"destination": "net.hadess.PowerProfiles"}).validate())
+	require.Error(t, NewDBusProperty(entity.Meta{
+		"destination": "net.hadess.PowerProfiles",
+		"path":        "net/hadess/PowerProfiles",
+		"interface":   "net.hadess.PowerProfiles",
+		"property":    "ActiveProfile",
+	}).validate())
+}
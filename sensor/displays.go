@@ -0,0 +1,148 @@
+package sensor
+
+import (
+	"bytes"
+	"context"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strings"
+
+	"hacompanion/entity"
+)
+
+// reDRMConnector matches connector directories like card1-HDMI-A-1.
+var reDRMConnector = regexp.MustCompile(`^card\d+-(.+)$`)
+
+// internalConnectors are the connector types that are used for built-in panels.
+var internalConnectors = []string{"eDP", "LVDS", "DSI"}
+
+// Displays reports the connected monitors and their power state from the DRM subsystem.
+type Displays struct {
+	root string
+}
+
+func NewDisplays() *Displays {
+	return &Displays{root: "/sys/class/drm"}
+}
+
+// display contains the state of a single connected monitor.
+type display struct {
+	// connector is the name including the card, e.g. card1-DP-3, as the
+	// same connector names are used by multiple GPUs.
+	connector    string
+	enabled      bool
+	dpms         string
+	manufacturer string
+	model        string
+}
+
+func (d Displays) Run(ctx context.Context) (*entity.Payload, error) {
+	entries, err := os.ReadDir(d.root)
+	if err != nil {
+		return nil, err
+	}
+	var displays []display
+	for _, entry := range entries {
+		if !reDRMConnector.MatchString(entry.Name()) {
+			continue
+		}
+		dir := filepath.Join(d.root, entry.Name())
+		if d.read(dir, "status") != "connected" {
+			continue
+		}
+		dsp := display{
+			connector: entry.Name(),
+			enabled:   d.read(dir, "enabled") == "enabled",
+			dpms:      strings.ToLower(d.read(dir, "dpms")),
+		}
+		if edid, err := os.ReadFile(filepath.Join(dir, "edid")); err == nil {
+			dsp.manufacturer, dsp.model = parseEDID(edid)
+		}
+		displays = append(displays, dsp)
+	}
+	return d.process(displays), nil
+}
+
+func (d Displays) read(dir, name string) string {
+	b, err := os.ReadFile(filepath.Join(dir, name))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+func (d Displays) process(displays []display) *entity.Payload {
+	var external, poweredOn int
+	list := make([]map[string]interface{}, 0, len(displays))
+	for _, dsp := range displays {
+		internal := false
+		if match := reDRMConnector.FindStringSubmatch(dsp.connector); match != nil {
+			for _, prefix := range internalConnectors {
+				if strings.HasPrefix(match[1], prefix) {
+					internal = true
+				}
+			}
+		}
+		if !internal {
+			external++
+		}
+		// Displays without a CRTC are connected but not in use, e.g. a closed lid.
+		on := dsp.enabled && dsp.dpms == "on"
+		if on {
+			poweredOn++
+		}
+		info := map[string]interface{}{
+			"connector": dsp.connector,
+			"internal":  internal,
+			"enabled":   dsp.enabled,
+			"dpms":      dsp.dpms,
+		}
+		if dsp.manufacturer != "" {
+			info["manufacturer"] = dsp.manufacturer
+		}
+		if dsp.model != "" {
+			info["model"] = dsp.model
+		}
+		list = append(list, info)
+	}
+
+	p := entity.NewPayload()
+	p.State = len(displays)
+	p.Attributes["external"] = external
+	p.Attributes["powered_on"] = poweredOn > 0
+	p.Attributes["displays"] = list
+	switch {
+	case poweredOn == 0:
+		p.Icon = "mdi:monitor-off"
+	case poweredOn > 1:
+		p.Icon = "mdi:monitor-multiple"
+	}
+	return p
+}
+
+// parseEDID returns the manufacturer ID and the product name from an EDID block.
+func parseEDID(edid []byte) (manufacturer, model string) {
+	header := []byte{0x00, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x00}
+	if len(edid) < 128 || !bytes.Equal(edid[:8], header) {
+		return "", ""
+	}
+	// The manufacturer is encoded as three 5-bit letters.
+	id := uint16(edid[8])<<8 | uint16(edid[9])
+	manufacturer = string([]byte{
+		byte(id>>10&0x1f) + 'A' - 1,
+		byte(id>>5&0x1f) + 'A' - 1,
+		byte(id&0x1f) + 'A' - 1,
+	})
+	// The product name is stored in one of the four display descriptors.
+	for offset := 54; offset+18 <= 126; offset += 18 {
+		descriptor := edid[offset : offset+18]
+		if descriptor[0] != 0 || descriptor[1] != 0 || descriptor[3] != 0xfc {
+			continue
+		}
+		name, _, _ := bytes.Cut(descriptor[5:], []byte{0x0a})
+		model = strings.TrimSpace(string(name))
+		break
+	}
+	return manufacturer, model
+}
@@ -0,0 +1,71 @@
+package sensor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+// testEDID returns an EDID block with the given manufacturer ID and product name.
+func testEDID(id uint16, name string) []byte {
+	edid := make([]byte, 128)
+	copy(edid, []byte{0x00, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0x00})
+	edid[8], edid[9] = byte(id>>8), byte(id)
+	// The first descriptor contains the detailed timing, the name is in the second one.
+	edid[54] = 0x01
+	descriptor := edid[72:90]
+	descriptor[3] = 0xfc
+	copy(descriptor[5:], append([]byte(name), 0x0a, 0x20, 0x20))
+	return edid
+}
+
+func TestParseEDID(t *testing.T) {
+	manufacturer, model := parseEDID(testEDID(0x10ac, "DELL U2720Q"))
+	require.Equal(t, "DEL", manufacturer)
+	require.Equal(t, "DELL U2720Q", model)
+
+	manufacturer, model = parseEDID([]byte{0x00, 0x01})
+	require.Empty(t, manufacturer)
+	require.Empty(t, model)
+}
+
+func TestDisplays(t *testing.T) {
+	root := t.TempDir()
+	connectors := map[string]map[string]string{
+		"card1-eDP-1":       {"status": "connected", "enabled": "disabled", "dpms": "Off"},
+		"card1-DP-3":        {"status": "connected", "enabled": "enabled", "dpms": "On", "edid": string(testEDID(0x10ac, "DELL U2720Q"))},
+		"card1-HDMI-A-1":    {"status": "disconnected", "enabled": "disabled", "dpms": "Off"},
+		"card1-Writeback-1": {"status": "unknown", "enabled": "disabled", "dpms": "Off"},
+		// The same connector names are used by other GPUs.
+		"card2-DP-3": {"status": "connected", "enabled": "enabled", "dpms": "On"},
+	}
+	for dir, files := range connectors {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, dir), 0o755))
+		for name, content := range files {
+			require.NoError(t, os.WriteFile(filepath.Join(root, dir, name), []byte(content), 0o600))
+		}
+	}
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "card1"), 0o755))
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "renderD128"), 0o755))
+
+	p, err := Displays{root: root}.Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 3, p.State)
+	require.Equal(t, "mdi:monitor-multiple", p.Icon)
+	require.EqualValues(t, map[string]interface{}{
+		"external":   2,
+		"powered_on": true,
+		"displays": []map[string]interface{}{
+			{"connector": "card1-DP-3", "internal": false, "enabled": true, "dpms": "on", "manufacturer": "DEL", "model": "DELL U2720Q"},
+			{"connector": "card1-eDP-1", "internal": true, "enabled": false, "dpms": "off"},
+			{"connector": "card2-DP-3", "internal": false, "enabled": true, "dpms": "on"},
+		},
+	}, p.Attributes)
+
+	p = Displays{}.process([]display{{connector: "card0-eDP-1", enabled: true, dpms: "off"}})
+	require.Equal(t, false, p.Attributes["powered_on"])
+	require.Equal(t, "mdi:monitor-off", p.Icon)
+}
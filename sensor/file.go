@@ -0,0 +1,195 @@
+package sensor
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"syscall"
+	"unsafe"
+
+	"hacompanion/entity"
+	"hacompanion/util"
+)
+
+// File reports a value read from a text or JSON file, like a sysfs attribute.
+type File struct {
+	path    string
+	line    int
+	regex   *regexp.Regexp
+	pointer string
+	scale   float64
+	watch   bool
+	// err is set if the configuration is invalid and returned on every run.
+	err error
+}
+
+func NewFile(m entity.Meta) *File {
+	f := &File{
+		path:    m.GetString("path"),
+		line:    m.GetInt("line"),
+		pointer: m.GetString("json"),
+		scale:   m.GetFloat("scale"),
+		watch:   m.GetBool("watch"),
+	}
+	if expr := m.GetString("regex"); expr != "" {
+		f.regex, f.err = regexp.Compile(expr)
+	}
+	switch {
+	case f.path == "":
+		f.err = errors.New("the file sensor requires a path")
+	case f.regex != nil && f.pointer != "":
+		f.err = errors.New("the file sensor supports either regex or json, not both")
+	}
+	return f
+}
+
+func (f File) Run(ctx context.Context) (*entity.Payload, error) {
+	if f.err != nil {
+		return nil, f.err
+	}
+	path, err := f.resolve()
+	if err != nil {
+		return nil, err
+	}
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	p, err := f.process(string(b))
+	if err != nil {
+		return nil, fmt.Errorf("failed to extract value from %s: %w", path, err)
+	}
+	p.Attributes["path"] = path
+	return p, nil
+}
+
+// resolve returns the first file that matches the configured path pattern.
+func (f File) resolve() (string, error) {
+	matches, err := filepath.Glob(f.path)
+	if err != nil {
+		return "", err
+	}
+	if len(matches) == 0 {
+		return "", fmt.Errorf("no file matches %s", f.path)
+	}
+	return matches[0], nil
+}
+
+func (f File) process(content string) (*entity.Payload, error) {
+	value := content
+	if f.line > 0 {
+		lines := strings.Split(content, "\n")
+		if f.line > len(lines) {
+			return nil, fmt.Errorf("line %d does not exist", f.line)
+		}
+		value = lines[f.line-1]
+	}
+
+	p := entity.NewPayload()
+	switch {
+	case f.regex != nil:
+		match := f.regex.FindStringSubmatch(value)
+		if match == nil {
+			return nil, fmt.Errorf("regex %s does not match", f.regex)
+		}
+		// The first capture group is used, or the whole match if there is none.
+		value = match[0]
+		if len(match) > 1 {
+			value = match[1]
+		}
+	case f.pointer != "":
+		// Unlike the rest sensor, the file sensor only supports JSON pointers.
+		if !isJSONPointer(f.pointer) {
+			return nil, fmt.Errorf("invalid JSON pointer %s", f.pointer)
+		}
+		var doc interface{}
+		if err := json.Unmarshal([]byte(value), &doc); err != nil {
+			return nil, err
+		}
+		result, err := jsonValue(doc, f.pointer)
+		if err != nil {
+			return nil, err
+		}
+		if s, ok := result.(string); ok {
+			value = s
+		} else {
+			b, err := json.Marshal(result)
+			if err != nil {
+				return nil, err
+			}
+			value = string(b)
+		}
+	}
+	value = strings.TrimSpace(value)
+
+	number, err := strconv.ParseFloat(value, 64)
+	switch {
+	case err == nil && f.scale != 0:
+		p.State = util.RoundToTwoDecimals(number * f.scale)
+	case err == nil:
+		p.State = number
+	case f.scale != 0:
+		return nil, fmt.Errorf("value %q is not a number and can't be scaled", value)
+	default:
+		p.State = value
+	}
+	return p, nil
+}
+
+// Watch pushes updates when the file is written or replaced. Most sysfs and
+// procfs files don't emit inotify events, they are updated on the regular interval.
+func (f File) Watch(ctx context.Context, notify func()) error {
+	if !f.watch || f.err != nil {
+		return nil
+	}
+	path, err := f.resolve()
+	if err != nil {
+		return err
+	}
+	fd, err := syscall.InotifyInit1(syscall.IN_CLOEXEC | syscall.IN_NONBLOCK)
+	if err != nil {
+		return fmt.Errorf("failed to initialize inotify: %w", err)
+	}
+	// The directory is watched to detect files that are replaced instead of written to.
+	mask := uint32(syscall.IN_CLOSE_WRITE | syscall.IN_MODIFY | syscall.IN_MOVED_TO | syscall.IN_CREATE)
+	if _, err = syscall.InotifyAddWatch(fd, filepath.Dir(path), mask); err != nil {
+		syscall.Close(fd)
+		return fmt.Errorf("failed to watch %s: %w", path, err)
+	}
+	// A non-blocking file uses the runtime poller, so closing it interrupts the read.
+	events := os.NewFile(uintptr(fd), "inotify")
+	go func() {
+		<-ctx.Done()
+		events.Close()
+	}()
+
+	name := filepath.Base(path)
+	buf := make([]byte, 4096)
+	for {
+		n, err := events.Read(buf)
+		if err != nil {
+			if ctx.Err() != nil {
+				return nil
+			}
+			return fmt.Errorf("failed to read inotify events: %w", err)
+		}
+		for offset := 0; offset+syscall.SizeofInotifyEvent <= n; {
+			event := (*syscall.InotifyEvent)(unsafe.Pointer(&buf[offset]))
+			nameStart := offset + syscall.SizeofInotifyEvent
+			nameEnd := nameStart + int(event.Len)
+			if nameEnd > n {
+				break
+			}
+			if strings.TrimRight(string(buf[nameStart:nameEnd]), "\x00") == name {
+				notify()
+			}
+			offset = nameEnd
+		}
+	}
+}
@@ -0,0 +1,95 @@
+package sensor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"sync"
+	"testing"
+	"time"
+
+	"hacompanion/entity"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestFile(t *testing.T) {
+	cases := []struct {
+		name    string
+		meta    entity.Meta
+		content string
+		state   interface{}
+	}{
+		{"plain number", entity.Meta{}, "1200\n", 1200.0},
+		{"scale", entity.Meta{"scale": 0.001}, "45312\n", 45.31},
+		{"string", entity.Meta{}, "performance\n", "performance"},
+		{"line", entity.Meta{"line": int64(2)}, "first\nsecond\n", "second"},
+		{"regex group", entity.Meta{"regex": `speed:\s+(\d+)`}, "status:\t\tenabled\nspeed:\t\t2716\nlevel:\t\tauto\n", 2716.0},
+		{"regex match", entity.Meta{"regex": `[a-z]+`, "line": int64(3)}, "status:\t\tenabled\nspeed:\t\t2716\nlevel:\t\tauto\n", "level"},
+		{"json", entity.Meta{"json": "/last_run/result"}, `{"last_run":{"result":"success"}}`, "success"},
+		{"json array", entity.Meta{"json": "/repos/1/size", "scale": 2}, `{"repos":[{"size":1},{"size":2.5}]}`, 5.0},
+		{"json escaped", entity.Meta{"json": "/a~1b"}, `{"a/b":true}`, "true"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.meta["path"] = "/unused"
+			f := NewFile(tc.meta)
+			require.NoError(t, f.err)
+			p, err := f.process(tc.content)
+			require.NoError(t, err)
+			require.Equal(t, tc.state, p.State)
+		})
+	}
+}
+
+func TestFileErrors(t *testing.T) {
+	_, err := NewFile(entity.Meta{"path": "/unused", "scale": 2}).process("on")
+	require.Error(t, err)
+	_, err = NewFile(entity.Meta{"path": "/unused", "json": "/missing"}).process(`{}`)
+	require.EqualError(t, err, "JSON pointer /missing: key missing does not exist")
+	// Dotted paths are only supported by the rest sensor.
+	_, err = NewFile(entity.Meta{"path": "/unused", "json": "last_run.result"}).process(`{"last_run":{"result":"success"}}`)
+	require.EqualError(t, err, "invalid JSON pointer last_run.result")
+	_, err = NewFile(entity.Meta{"path": "/unused", "regex": `\d+`}).process("none")
+	require.Error(t, err)
+	require.Error(t, NewFile(entity.Meta{}).err)
+}
+
+func TestFileRunAndWatch(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "brightness")
+	require.NoError(t, os.WriteFile(path, []byte("96000\n"), 0o600))
+
+	f := NewFile(entity.Meta{"path": filepath.Join(dir, "bright*"), "watch": true})
+	p, err := f.Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 96000.0, p.State)
+	require.Equal(t, path, p.Attributes["path"])
+
+	ctx, cancel := context.WithCancel(context.Background())
+	notified := make(chan struct{})
+	var once sync.Once
+	done := make(chan error)
+	go func() {
+		done <- f.Watch(ctx, func() { once.Do(func() { close(notified) }) })
+	}()
+	// The watcher registers asynchronously, so the file is written
+	// until the change is reported instead of waiting for a fixed time.
+	require.NoError(t, os.WriteFile(filepath.Join(dir, "other"), []byte("1"), 0o600))
+	timeout := time.After(5 * time.Second)
+	tick := time.NewTicker(20 * time.Millisecond)
+	defer tick.Stop()
+wait:
+	for {
+		require.NoError(t, os.WriteFile(path, []byte("48000\n"), 0o600))
+		select {
+		case <-notified:
+			break wait
+		case <-tick.C:
+		case <-timeout:
+			t.Fatal("no change was reported")
+		}
+	}
+	cancel()
+	require.NoError(t, <-done)
+}
@@ -0,0 +1,52 @@
+package sensor
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// jsonValue returns the value of a decoded JSON document that an expression refers to.
+// The expression is either a JSON pointer as defined in RFC 6901 like /items/0/name,
+// or a dotted path like items.0.name.
+func jsonValue(doc interface{}, expr string) (interface{}, error) {
+	if expr == "" {
+		return doc, nil
+	}
+	var tokens []string
+	kind := "JSON path"
+	if isJSONPointer(expr) {
+		kind = "JSON pointer"
+		replacer := strings.NewReplacer("~1", "/", "~0", "~")
+		for _, token := range strings.Split(expr[1:], "/") {
+			tokens = append(tokens, replacer.Replace(token))
+		}
+	} else {
+		tokens = strings.Split(expr, ".")
+	}
+	current := doc
+	for _, token := range tokens {
+		switch value := current.(type) {
+		case map[string]interface{}:
+			next, ok := value[token]
+			if !ok {
+				return nil, fmt.Errorf("%s %s: key %s does not exist", kind, expr, token)
+			}
+			current = next
+		case []interface{}:
+			index, err := strconv.Atoi(token)
+			if err != nil || index < 0 || index >= len(value) {
+				return nil, fmt.Errorf("%s %s: invalid index %s", kind, expr, token)
+			}
+			current = value[index]
+		default:
+			return nil, fmt.Errorf("%s %s: can't descend into %s", kind, expr, token)
+		}
+	}
+	return current, nil
+}
+
+// isJSONPointer reports if an expression is a JSON pointer instead of a dotted path.
+func isJSONPointer(expr string) bool {
+	return strings.HasPrefix(expr, "/")
+}
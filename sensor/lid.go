@@ -0,0 +1,137 @@
+package sensor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strings"
+
+	"hacompanion/entity"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// Lid reports if the laptop lid is open. The state is read from ACPI,
+// systems without the ACPI button interface use logind instead.
+type Lid struct {
+	root string
+	conn *busConnection
+}
+
+func NewLid() *Lid {
+	return &Lid{
+		root: "/proc/acpi/button/lid",
+		conn: newBusConnection(busSystem),
+	}
+}
+
+func (l Lid) Run(ctx context.Context) (*entity.Payload, error) {
+	closed, acpiErr := l.readACPI()
+	// logind also knows if the laptop is docked, which is
+	// reported if available, even if ACPI is used for the state.
+	props, logindErr := l.logind(ctx)
+	source := "acpi"
+	if acpiErr != nil {
+		if logindErr != nil {
+			return nil, fmt.Errorf("failed to get lid state: %w", errors.Join(acpiErr, logindErr))
+		}
+		lidClosed, ok := props["LidClosed"].(bool)
+		if !ok {
+			return nil, errors.New("failed to get lid state: invalid LidClosed property")
+		}
+		closed, source = lidClosed, "logind"
+	}
+	docked, _ := props["Docked"].(bool)
+	return l.process(closed, source, docked, logindErr == nil), nil
+}
+
+func (l Lid) process(closed bool, source string, docked, dockedKnown bool) *entity.Payload {
+	p := entity.NewPayload()
+	p.State = !closed
+	p.Attributes["source"] = source
+	if dockedKnown {
+		p.Attributes["docked"] = docked
+	}
+	if closed {
+		p.Icon = "mdi:laptop-off"
+	}
+	return p
+}
+
+// readACPI reads the lid state from a file like /proc/acpi/button/lid/LID0/state.
+func (l Lid) readACPI() (closed bool, err error) {
+	paths, err := filepath.Glob(filepath.Join(l.root, "*", "state"))
+	if err != nil {
+		return false, err
+	}
+	if len(paths) == 0 {
+		return false, fmt.Errorf("no lid found in %s", l.root)
+	}
+	b, err := os.ReadFile(paths[0])
+	if err != nil {
+		return false, err
+	}
+	// The file contains a line like "state:      open".
+	_, state, ok := strings.Cut(string(b), ":")
+	switch strings.TrimSpace(state) {
+	case "open":
+		return false, nil
+	case "closed":
+		return true, nil
+	}
+	if !ok {
+		return false, fmt.Errorf("invalid lid state %q", string(b))
+	}
+	return false, fmt.Errorf("unknown lid state %q", strings.TrimSpace(state))
+}
+
+// logind returns the lid and dock properties of the logind manager.
+func (l Lid) logind(ctx context.Context) (map[string]interface{}, error) {
+	conn, err := l.conn.get()
+	if err != nil {
+		return nil, err
+	}
+	var props map[string]dbus.Variant
+	err = conn.Object(LogindDestination, LogindPath).
+		CallWithContext(ctx, dbusPropertiesInterface+".GetAll", 0, LogindManagerInterface).
+		Store(&props)
+	if err != nil {
+		return nil, fmt.Errorf("failed to get logind properties: %w", err)
+	}
+	values := make(map[string]interface{}, 2)
+	for _, name := range []string{"LidClosed", "Docked"} {
+		if value, ok := props[name]; ok {
+			values[name] = value.Value()
+		}
+	}
+	return values, nil
+}
+
+// Watch pushes updates when logind reports that the lid was opened or closed,
+// or the laptop was docked or undocked.
+func (l Lid) Watch(ctx context.Context, notify func()) error {
+	conn, err := connectBus(busSystem)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	return watchSignals(ctx, conn, func(sig *dbus.Signal) {
+		iface, changed, ok := changedProperties(sig)
+		if !ok || iface != LogindManagerInterface {
+			return
+		}
+		for _, name := range []string{"LidClosed", "Docked"} {
+			if _, ok := changed[name]; ok {
+				notify()
+				return
+			}
+		}
+	},
+		dbus.WithMatchSender(LogindDestination),
+		dbus.WithMatchInterface(dbusPropertiesInterface),
+		dbus.WithMatchMember(dbusPropertiesChanged),
+		dbus.WithMatchObjectPath(LogindPath),
+	)
+}
@@ -0,0 +1,48 @@
+package sensor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestLidACPI(t *testing.T) {
+	cases := []struct {
+		content string
+		closed  bool
+		err     bool
+	}{
+		{"state:      open\n", false, false},
+		{"state:      closed\n", true, false},
+		{"state:      unknown\n", false, true},
+	}
+	for _, tc := range cases {
+		root := t.TempDir()
+		require.NoError(t, os.MkdirAll(filepath.Join(root, "LID0"), 0o755))
+		require.NoError(t, os.WriteFile(filepath.Join(root, "LID0", "state"), []byte(tc.content), 0o600))
+		closed, err := Lid{root: root}.readACPI()
+		if tc.err {
+			require.Error(t, err)
+			continue
+		}
+		require.NoError(t, err)
+		require.Equal(t, tc.closed, closed)
+	}
+
+	_, err := Lid{root: t.TempDir()}.readACPI()
+	require.Error(t, err)
+}
+
+func TestLidProcess(t *testing.T) {
+	p := Lid{}.process(true, "acpi", true, true)
+	require.Equal(t, false, p.State)
+	require.Equal(t, "mdi:laptop-off", p.Icon)
+	require.EqualValues(t, map[string]interface{}{"source": "acpi", "docked": true}, p.Attributes)
+
+	p = Lid{}.process(false, "logind", false, false)
+	require.Equal(t, true, p.State)
+	require.Equal(t, "", p.Icon)
+	require.EqualValues(t, map[string]interface{}{"source": "logind"}, p.Attributes)
+}
@@ -0,0 +1,190 @@
+package sensor
+
+import (
+	"context"
+	"fmt"
+	"os"
+	"time"
+
+	"hacompanion/entity"
+
+	"github.com/godbus/dbus/v5"
+)
+
+// The logind names are exported as they are also used for the sleep inhibitor.
+const (
+	LogindDestination      = "org.freedesktop.login1"
+	LogindPath             = "/org/freedesktop/login1"
+	LogindManagerInterface = "org.freedesktop.login1.Manager"
+
+	logindUserInterface    = "org.freedesktop.login1.User"
+	logindSessionInterface = "org.freedesktop.login1.Session"
+)
+
+// loginSession reads properties of the user's logind session.
+type loginSession struct {
+	// id is the session ID. If it is empty, the graphical session of the current user is used.
+	id   string
+	conn *busConnection
+}
+
+func newLoginSession(m entity.Meta) loginSession {
+	return loginSession{
+		id:   m.GetString("session"),
+		conn: newBusConnection(busSystem),
+	}
+}
+
+// path resolves the object path of the session.
+func (l loginSession) path(ctx context.Context, conn *dbus.Conn) (dbus.ObjectPath, error) {
+	manager := conn.Object(LogindDestination, LogindPath)
+	var path dbus.ObjectPath
+	if l.id != "" {
+		err := manager.CallWithContext(ctx, LogindManagerInterface+".GetSession", 0, l.id).Store(&path)
+		return path, err
+	}
+	// The companion usually runs as a service outside of the session,
+	// so the session is looked up by the user's display session.
+	var userPath dbus.ObjectPath
+	//nolint:gosec
+	err := manager.CallWithContext(ctx, LogindManagerInterface+".GetUser", 0, uint32(os.Getuid())).Store(&userPath)
+	if err != nil {
+		return "", fmt.Errorf("failed to get logind user: %w", err)
+	}
+	value, err := conn.Object(LogindDestination, userPath).GetProperty(logindUserInterface + ".Display")
+	if err != nil {
+		return "", fmt.Errorf("failed to get display session: %w", err)
+	}
+	// The display session is a struct of the session ID and its object path.
+	display, ok := value.Value().([]interface{})
+	if !ok || len(display) < 2 {
+		return "", fmt.Errorf("invalid display session %v", value)
+	}
+	path, ok = display[1].(dbus.ObjectPath)
+	if !ok || path == "/" {
+		return "", fmt.Errorf("user has no display session")
+	}
+	return path, nil
+}
+
+// properties returns the given session properties.
+func (l loginSession) properties(ctx context.Context, names ...string) (map[string]interface{}, error) {
+	conn, err := l.conn.get()
+	if err != nil {
+		return nil, err
+	}
+	path, err := l.path(ctx, conn)
+	if err != nil {
+		return nil, err
+	}
+	obj := conn.Object(LogindDestination, path)
+	props := make(map[string]interface{}, len(names)+1)
+	for _, name := range names {
+		value, err := obj.GetProperty(logindSessionInterface + "." + name)
+		if err != nil {
+			return nil, fmt.Errorf("failed to get session property %s: %w", name, err)
+		}
+		props[name] = value.Value()
+	}
+	var id string
+	if err = obj.StoreProperty(logindSessionInterface+".Id", &id); err == nil {
+		props["Id"] = id
+	}
+	return props, nil
+}
+
+// watch calls notify whenever one of the given session properties changes.
+func (l loginSession) watch(ctx context.Context, notify func(), names ...string) error {
+	conn, err := connectBus(busSystem)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	path, err := l.path(ctx, conn)
+	if err != nil {
+		return err
+	}
+	return watchSignals(ctx, conn, func(sig *dbus.Signal) {
+		iface, changed, ok := changedProperties(sig)
+		if !ok || iface != logindSessionInterface {
+			return
+		}
+		for _, name := range names {
+			if _, ok := changed[name]; ok {
+				notify()
+				return
+			}
+		}
+	},
+		dbus.WithMatchSender(LogindDestination),
+		dbus.WithMatchInterface(dbusPropertiesInterface),
+		dbus.WithMatchMember(dbusPropertiesChanged),
+		dbus.WithMatchObjectPath(path),
+	)
+}
+
+// ScreenLocked reports if the user's session is locked.
+type ScreenLocked struct {
+	session loginSession
+}
+
+func NewScreenLocked(m entity.Meta) *ScreenLocked {
+	return &ScreenLocked{session: newLoginSession(m)}
+}
+
+func (s ScreenLocked) Run(ctx context.Context) (*entity.Payload, error) {
+	props, err := s.session.properties(ctx, "LockedHint")
+	if err != nil {
+		return nil, err
+	}
+	return s.process(props), nil
+}
+
+func (s ScreenLocked) process(props map[string]interface{}) *entity.Payload {
+	p := entity.NewPayload()
+	locked := props["LockedHint"] == true
+	p.State = locked
+	p.Attributes["session"] = props["Id"]
+	if locked {
+		p.Icon = "mdi:monitor-lock"
+	}
+	return p
+}
+
+func (s ScreenLocked) Watch(ctx context.Context, notify func()) error {
+	return s.session.watch(ctx, notify, "LockedHint")
+}
+
+// UserIdle reports if the user's session is idle.
+type UserIdle struct {
+	session loginSession
+}
+
+func NewUserIdle(m entity.Meta) *UserIdle {
+	return &UserIdle{session: newLoginSession(m)}
+}
+
+func (u UserIdle) Run(ctx context.Context) (*entity.Payload, error) {
+	props, err := u.session.properties(ctx, "IdleHint", "IdleSinceHint")
+	if err != nil {
+		return nil, err
+	}
+	return u.process(props), nil
+}
+
+func (u UserIdle) process(props map[string]interface{}) *entity.Payload {
+	p := entity.NewPayload()
+	idle := props["IdleHint"] == true
+	p.State = idle
+	p.Attributes["session"] = props["Id"]
+	// IdleSinceHint is the time of the last change of IdleHint in microseconds.
+	if since, ok := props["IdleSinceHint"].(uint64); ok && idle && since > 0 {
+		//nolint:gosec
+		p.Attributes["idle_since"] = time.UnixMicro(int64(since)).Format(time.RFC3339)
+	}
+	return p
+}
+
+func (u UserIdle) Watch(ctx context.Context, notify func()) error {
+	return u.session.watch(ctx, notify, "IdleHint")
+}
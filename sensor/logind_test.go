@@ -0,0 +1,50 @@
+package sensor
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestScreenLockedProcess(t *testing.T) {
+	p := ScreenLocked{}.process(map[string]interface{}{"LockedHint": true, "Id": "2"})
+	require.Equal(t, true, p.State)
+	require.Equal(t, "mdi:monitor-lock", p.Icon)
+	require.EqualValues(t, map[string]interface{}{"session": "2"}, p.Attributes)
+
+	p = ScreenLocked{}.process(map[string]interface{}{"LockedHint": false, "Id": "2"})
+	require.Equal(t, false, p.State)
+	require.Equal(t, "", p.Icon)
+
+	// Missing or invalid properties are reported as unlocked.
+	p = ScreenLocked{}.process(map[string]interface{}{})
+	require.Equal(t, false, p.State)
+}
+
+func TestUserIdleProcess(t *testing.T) {
+	since := time.Date(2024, 5, 1, 12, 30, 0, 0, time.UTC)
+	cases := []struct {
+		name     string
+		props    map[string]interface{}
+		idle     bool
+		hasSince bool
+	}{
+		{"idle", map[string]interface{}{"IdleHint": true, "IdleSinceHint": uint64(since.UnixMicro()), "Id": "2"}, true, true},
+		{"active", map[string]interface{}{"IdleHint": false, "IdleSinceHint": uint64(since.UnixMicro()), "Id": "2"}, false, false},
+		{"idle without timestamp", map[string]interface{}{"IdleHint": true, "IdleSinceHint": uint64(0), "Id": "2"}, true, false},
+		{"invalid timestamp", map[string]interface{}{"IdleHint": true, "IdleSinceHint": "now", "Id": "2"}, true, false},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p := UserIdle{}.process(tc.props)
+			require.Equal(t, tc.idle, p.State)
+			require.Equal(t, "2", p.Attributes["session"])
+			value, ok := p.Attributes["idle_since"]
+			require.Equal(t, tc.hasSince, ok)
+			if tc.hasSince {
+				require.Equal(t, since.Local().Format(time.RFC3339), value)
+			}
+		})
+	}
+}
@@ -0,0 +1,279 @@
+package sensor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"time"
+
+	"hacompanion/entity"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	mprisPath            = "/org/mpris/MediaPlayer2"
+	mprisPrefix          = "org.mpris.MediaPlayer2."
+	mprisRootInterface   = "org.mpris.MediaPlayer2"
+	mprisPlayerInterface = "org.mpris.MediaPlayer2.Player"
+	dbusInterface        = "org.freedesktop.DBus"
+
+	// mediaSeekStep is the time skipped by the fast_forward and rewind commands.
+	mediaSeekStep = 10 * time.Second
+)
+
+// mediaCommands maps the media commands of Home Assistant to MPRIS methods.
+var mediaCommands = map[string]string{
+	"play":       "Play",
+	"pause":      "Pause",
+	"play_pause": "PlayPause",
+	"stop":       "Stop",
+	"next":       "Next",
+	"previous":   "Previous",
+}
+
+// MediaPlayer reports the state of MPRIS media players on the session bus.
+type MediaPlayer struct {
+	// player limits the sensor to players whose bus name contains this value.
+	player string
+	conn   *busConnection
+}
+
+func NewMediaPlayer(m entity.Meta) *MediaPlayer {
+	return &MediaPlayer{
+		player: strings.ToLower(m.GetString("player")),
+		conn:   newBusConnection(busSession),
+	}
+}
+
+// mediaPlayerState contains the state of a single MPRIS player.
+type mediaPlayerState struct {
+	busName  string
+	identity string
+	status   string
+	metadata map[string]dbus.Variant
+	position int64
+}
+
+func (mp *MediaPlayer) Run(ctx context.Context) (*entity.Payload, error) {
+	conn, err := mp.conn.get()
+	if err != nil {
+		return nil, err
+	}
+	names, err := mp.players(ctx, conn, "")
+	if err != nil {
+		return nil, err
+	}
+	var players []mediaPlayerState
+	for _, name := range names {
+		state, err := mp.playerState(conn, name)
+		if err != nil {
+			continue
+		}
+		players = append(players, state)
+	}
+	return mp.process(players), nil
+}
+
+func (mp *MediaPlayer) process(players []mediaPlayerState) *entity.Payload {
+	p := entity.NewPayload()
+	identities := make([]string, 0, len(players))
+	for _, player := range players {
+		identities = append(identities, player.identity)
+	}
+	p.Attributes["players"] = identities
+
+	active, ok := mp.activePlayer(players)
+	if !ok {
+		p.State = "idle"
+		return p
+	}
+	p.State = strings.ToLower(active.status)
+	p.Attributes["player"] = active.identity
+	if title, ok := active.metadata["xesam:title"].Value().(string); ok {
+		p.Attributes["title"] = title
+	}
+	if artists, ok := active.metadata["xesam:artist"].Value().([]string); ok {
+		p.Attributes["artist"] = strings.Join(artists, ", ")
+	}
+	if album, ok := active.metadata["xesam:album"].Value().(string); ok {
+		p.Attributes["album"] = album
+	}
+	if artURL, ok := active.metadata["mpris:artUrl"].Value().(string); ok {
+		p.Attributes["art_url"] = artURL
+	}
+	// MPRIS reports times in microseconds, they are reported in seconds.
+	if length, ok := active.metadata["mpris:length"].Value().(int64); ok && length > 0 {
+		p.Attributes["duration"] = length / int64(time.Second/time.Microsecond)
+	}
+	if active.position > 0 {
+		p.Attributes["position"] = active.position / int64(time.Second/time.Microsecond)
+	}
+	switch p.State {
+	case "playing":
+		p.Icon = "mdi:play"
+	case "paused":
+		p.Icon = "mdi:pause"
+	}
+	return p
+}
+
+// activePlayer returns the player that is playing, or the first paused player.
+func (mp *MediaPlayer) activePlayer(players []mediaPlayerState) (mediaPlayerState, bool) {
+	for _, status := range []string{"Playing", "Paused"} {
+		for _, player := range players {
+			if player.status == status {
+				return player, true
+			}
+		}
+	}
+	if len(players) > 0 {
+		return players[0], true
+	}
+	return mediaPlayerState{}, false
+}
+
+// players returns the bus names of all MPRIS players, filtered by the configured player
+// and the given filter. The names are sorted to get a stable order between runs.
+func (mp *MediaPlayer) players(ctx context.Context, conn *dbus.Conn, filter string) ([]string, error) {
+	var names []string
+	err := conn.BusObject().CallWithContext(ctx, dbusInterface+".ListNames", 0).Store(&names)
+	if err != nil {
+		return nil, fmt.Errorf("failed to list D-Bus names: %w", err)
+	}
+	var players []string
+	for _, name := range names {
+		if !strings.HasPrefix(name, mprisPrefix) {
+			continue
+		}
+		lower := strings.ToLower(name)
+		if !strings.Contains(lower, mp.player) || !strings.Contains(lower, strings.ToLower(filter)) {
+			continue
+		}
+		players = append(players, name)
+	}
+	sort.Strings(players)
+	return players, nil
+}
+
+func (mp *MediaPlayer) playerState(conn *dbus.Conn, name string) (mediaPlayerState, error) {
+	obj := conn.Object(name, mprisPath)
+	state := mediaPlayerState{busName: name, identity: strings.TrimPrefix(name, mprisPrefix)}
+	if err := obj.StoreProperty(mprisPlayerInterface+".PlaybackStatus", &state.status); err != nil {
+		return state, err
+	}
+	var identity string
+	if err := obj.StoreProperty(mprisRootInterface+".Identity", &identity); err == nil && identity != "" {
+		state.identity = identity
+	}
+	if err := obj.StoreProperty(mprisPlayerInterface+".Metadata", &state.metadata); err != nil {
+		state.metadata = map[string]dbus.Variant{}
+	}
+	// Not all players support reporting the position.
+	_ = obj.StoreProperty(mprisPlayerInterface+".Position", &state.position)
+	return state, nil
+}
+
+// HandleCommand controls the active player with the "command_media" notification.
+// The player can be selected with the media_package_name data field.
+func (mp *MediaPlayer) HandleCommand(ctx context.Context, command string, data map[string]interface{}) (bool, error) {
+	if command != "command_media" {
+		return false, nil
+	}
+	mediaCommand, _ := data["media_command"].(string)
+	if mediaCommand == "" {
+		return true, errors.New("command_media requires media_command to be set")
+	}
+	conn, err := mp.conn.get()
+	if err != nil {
+		return true, err
+	}
+	filter, _ := data["media_package_name"].(string)
+	names, err := mp.players(ctx, conn, filter)
+	if err != nil {
+		return true, err
+	}
+	var players []mediaPlayerState
+	for _, name := range names {
+		if state, stateErr := mp.playerState(conn, name); stateErr == nil {
+			players = append(players, state)
+		}
+	}
+	active, ok := mp.activePlayer(players)
+	if !ok {
+		return true, errors.New("no media player found")
+	}
+	return true, mp.control(ctx, conn.Object(active.busName, mprisPath), active, mediaCommand, data)
+}
+
+// mediaCaller calls methods on a media player, it is implemented by dbus.BusObject.
+type mediaCaller interface {
+	CallWithContext(ctx context.Context, method string, flags dbus.Flags, args ...interface{}) *dbus.Call
+}
+
+// control runs a media command on the given player.
+func (mp *MediaPlayer) control(ctx context.Context, player mediaCaller, state mediaPlayerState, mediaCommand string, data map[string]interface{}) error {
+	if method, ok := mediaCommands[mediaCommand]; ok {
+		return player.CallWithContext(ctx, mprisPlayerInterface+"."+method, 0).Err
+	}
+	var offset time.Duration
+	switch mediaCommand {
+	case "fast_forward":
+		offset = mediaSeekStep
+	case "rewind":
+		offset = -mediaSeekStep
+	case "seek":
+		// media_position is the absolute target position in seconds.
+		position, ok := data["media_position"].(float64)
+		if !ok {
+			return errors.New("seek requires media_position to be set")
+		}
+		offset = time.Duration(position*float64(time.Second)) - time.Duration(state.position)*time.Microsecond
+	default:
+		return fmt.Errorf("unsupported media command %s", mediaCommand)
+	}
+	return player.CallWithContext(ctx, mprisPlayerInterface+".Seek", 0, offset.Microseconds()).Err
+}
+
+// Watch pushes updates whenever a player changes its state, or a player appears or vanishes.
+func (mp *MediaPlayer) Watch(ctx context.Context, notify func()) error {
+	conn, err := connectBus(busSession)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+	// Players are only known by their unique name in signals,
+	// so all players on the bus are watched.
+	err = conn.AddMatchSignalContext(ctx,
+		dbus.WithMatchSender(dbusInterface),
+		dbus.WithMatchInterface(dbusInterface),
+		dbus.WithMatchMember("NameOwnerChanged"),
+		dbus.WithMatchArg0Namespace(strings.TrimSuffix(mprisPrefix, ".")),
+	)
+	if err != nil {
+		return fmt.Errorf("failed to watch media players: %w", err)
+	}
+	return watchSignals(ctx, conn, func(sig *dbus.Signal) {
+		if sig.Name == dbusInterface+".NameOwnerChanged" {
+			notify()
+			return
+		}
+		iface, changed, ok := changedProperties(sig)
+		if !ok || iface != mprisPlayerInterface {
+			return
+		}
+		if _, ok := changed["PlaybackStatus"]; ok {
+			notify()
+			return
+		}
+		if _, ok := changed["Metadata"]; ok {
+			notify()
+		}
+	},
+		dbus.WithMatchInterface(dbusPropertiesInterface),
+		dbus.WithMatchMember(dbusPropertiesChanged),
+		dbus.WithMatchObjectPath(mprisPath),
+	)
+}
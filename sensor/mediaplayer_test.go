@@ -0,0 +1,115 @@
+package sensor
+
+import (
+	"context"
+	"testing"
+
+	"hacompanion/entity"
+
+	"github.com/godbus/dbus/v5"
+	"github.com/stretchr/testify/require"
+)
+
+func TestMediaPlayer(t *testing.T) {
+	players := []mediaPlayerState{
+		{
+			busName:  "org.mpris.MediaPlayer2.firefox.instance_1_42",
+			identity: "Mozilla Firefox",
+			status:   "Paused",
+			metadata: map[string]dbus.Variant{
+				"xesam:title": dbus.MakeVariant("Some video"),
+			},
+		},
+		{
+			busName:  "org.mpris.MediaPlayer2.spotify",
+			identity: "Spotify",
+			status:   "Playing",
+			metadata: map[string]dbus.Variant{
+				"xesam:title":  dbus.MakeVariant("Bohemian Rhapsody"),
+				"xesam:artist": dbus.MakeVariant([]string{"Queen"}),
+				"xesam:album":  dbus.MakeVariant("A Night at the Opera"),
+				"mpris:artUrl": dbus.MakeVariant("https://i.scdn.co/image/abc"),
+				"mpris:length": dbus.MakeVariant(int64(354000000)),
+			},
+			position: 61500000,
+		},
+	}
+	output := &entity.Payload{
+		State: "playing",
+		Icon:  "mdi:play",
+		Attributes: map[string]interface{}{
+			"players":  []string{"Mozilla Firefox", "Spotify"},
+			"player":   "Spotify",
+			"title":    "Bohemian Rhapsody",
+			"artist":   "Queen",
+			"album":    "A Night at the Opera",
+			"art_url":  "https://i.scdn.co/image/abc",
+			"duration": int64(354),
+			"position": int64(61),
+		},
+	}
+
+	mp := NewMediaPlayer(entity.Meta{})
+
+	require.EqualValues(t, output, mp.process(players))
+	require.Equal(t, "idle", mp.process(nil).State)
+}
+
+// fakeMediaCaller records the methods that are called on a media player.
+type fakeMediaCaller struct {
+	method string
+	args   []interface{}
+}
+
+func (f *fakeMediaCaller) CallWithContext(_ context.Context, method string, _ dbus.Flags, args ...interface{}) *dbus.Call {
+	f.method, f.args = method, args
+	return &dbus.Call{}
+}
+
+func TestMediaPlayerControl(t *testing.T) {
+	cases := []struct {
+		command string
+		data    map[string]interface{}
+		method  string
+		args    []interface{}
+		err     bool
+	}{
+		{command: "play_pause", method: "org.mpris.MediaPlayer2.Player.PlayPause"},
+		{command: "next", method: "org.mpris.MediaPlayer2.Player.Next"},
+		{command: "previous", method: "org.mpris.MediaPlayer2.Player.Previous"},
+		{command: "pause", method: "org.mpris.MediaPlayer2.Player.Pause"},
+		{command: "fast_forward", method: "org.mpris.MediaPlayer2.Player.Seek", args: []interface{}{int64(10000000)}},
+		{command: "rewind", method: "org.mpris.MediaPlayer2.Player.Seek", args: []interface{}{int64(-10000000)}},
+		// Seek is relative in MPRIS, the offset is calculated from the current position of 61.5s.
+		{command: "seek", data: map[string]interface{}{"media_position": float64(30)}, method: "org.mpris.MediaPlayer2.Player.Seek", args: []interface{}{int64(-31500000)}},
+		{command: "seek", err: true},
+		{command: "shuffle", err: true},
+	}
+	state := mediaPlayerState{busName: "org.mpris.MediaPlayer2.spotify", position: 61500000}
+	for _, tc := range cases {
+		t.Run(tc.command, func(t *testing.T) {
+			caller := &fakeMediaCaller{}
+			err := NewMediaPlayer(entity.Meta{}).control(context.Background(), caller, state, tc.command, tc.data)
+			if tc.err {
+				require.Error(t, err)
+				require.Empty(t, caller.method)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.method, caller.method)
+			require.Equal(t, tc.args, caller.args)
+		})
+	}
+}
+
+func TestMediaPlayerHandleCommand(t *testing.T) {
+	mp := NewMediaPlayer(entity.Meta{})
+
+	handled, err := mp.HandleCommand(context.Background(), "command_screen_on", nil)
+	require.False(t, handled)
+	require.NoError(t, err)
+
+	handled, err = mp.HandleCommand(context.Background(), "command_media", map[string]interface{}{})
+	require.True(t, handled)
+	require.Error(t, err)
+}
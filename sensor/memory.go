@@ -14,10 +14,14 @@ import (
 
 var reMemory = regexp.MustCompile(`(?mi)^\s?(?P<name>[^:]+):\s+(?P<value>\d+)`)
 
-type Memory struct{}
+// Memory reports the free memory. Inside a container with a memory limit,
+// the limit is reported instead of the memory of the host.
+type Memory struct {
+	cgroup *Cgroup
+}
 
 func NewMemory() *Memory {
-	return &Memory{}
+	return &Memory{cgroup: newCgroup("")}
 }
 
 func (m Memory) Run(ctx context.Context) (*entity.Payload, error) {
@@ -25,7 +29,19 @@ func (m Memory) Run(ctx context.Context) (*entity.Payload, error) {
 	if err != nil {
 		return nil, err
 	}
-	return m.process(string(b))
+	p, err := m.process(string(b))
+	if err != nil {
+		return nil, err
+	}
+	// Limits of cgroups on the host, e.g. of a systemd service, are not reported,
+	// as the memory of the host is still available to other processes.
+	if !m.cgroup.inContainer() {
+		return p, nil
+	}
+	if path, stats, ok := m.cgroup.memoryLimit(); ok {
+		m.processCgroup(p, path, stats)
+	}
+	return p, nil
 }
 
 func (m Memory) process(output string) (*entity.Payload, error) {
@@ -61,3 +77,20 @@ func (m Memory) process(output string) (*entity.Payload, error) {
 	}
 	return p, nil
 }
+
+// processCgroup replaces the memory values of the host with the limit and the usage of a cgroup.
+func (m Memory) processCgroup(p *entity.Payload, path string, stats cgroupStats) {
+	mb := func(bytes uint64) float64 {
+		return util.RoundToTwoDecimals(float64(bytes) / 1024 / 1024)
+	}
+	used := min(stats.memoryCurrent, stats.memoryMax)
+	p.State = mb(stats.memoryMax - used)
+	p.Attributes["mem_total"] = mb(stats.memoryMax)
+	// Like MemAvailable, the page cache that can be reclaimed is counted as available.
+	p.Attributes["mem_available"] = mb(stats.memoryMax - used + min(stats.inactiveFile, used))
+	if stats.hasSwapMax {
+		p.Attributes["swap_total"] = mb(stats.swapMax)
+		p.Attributes["swap_free"] = mb(stats.swapMax - min(stats.swapCurrent, stats.swapMax))
+	}
+	p.Attributes["cgroup"] = path
+}
@@ -33,3 +33,34 @@ func TestMemory(t *testing.T) {
 	require.NoError(t, err)
 	require.EqualValues(t, output, res)
 }
+
+func TestMemoryCgroup(t *testing.T) {
+	p, err := NewMemory().process(`
+		MemTotal:       16279032 kB
+		MemFree:          479256 kB
+		MemAvailable:    4469240 kB
+		SwapTotal:      16658428 kB
+		SwapFree:       15672316 kB
+	`)
+	require.NoError(t, err)
+
+	NewMemory().processCgroup(p, "/system.slice/docker-1234.scope", cgroupStats{
+		memoryCurrent: 512 * 1024 * 1024,
+		memoryMax:     2048 * 1024 * 1024,
+		hasMemoryMax:  true,
+		inactiveFile:  128 * 1024 * 1024,
+		swapCurrent:   64 * 1024 * 1024,
+		swapMax:       1024 * 1024 * 1024,
+		hasSwapMax:    true,
+	})
+	require.EqualValues(t, &entity.Payload{
+		State: 1536.0,
+		Attributes: map[string]interface{}{
+			"mem_total":     2048.0,
+			"mem_available": 1664.0,
+			"swap_total":    1024.0,
+			"swap_free":     960.0,
+			"cgroup":        "/system.slice/docker-1234.scope",
+		},
+	}, p)
+}
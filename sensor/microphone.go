@@ -0,0 +1,210 @@
+package sensor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"hacompanion/entity"
+)
+
+var (
+	rePactlProperty  = regexp.MustCompile(`^\s*([\w.]+) = "(.*)"$`)
+	reAmixerCapture  = regexp.MustCompile(`(?m)Capture \d+ \[\d{1,3}%\].*\[(on|off)\]`)
+	reALSAStatusLine = regexp.MustCompile(`^(\w+)\s*:\s*(.*)$`)
+)
+
+// Microphone reports if any application is capturing audio.
+type Microphone struct {
+	backend    string
+	asoundRoot string
+	procRoot   string
+}
+
+func NewMicrophone(m entity.Meta) *Microphone {
+	mic := &Microphone{
+		backend:    "alsa",
+		asoundRoot: "/proc/asound",
+		procRoot:   "/proc",
+	}
+	if backend := m.GetString("backend"); backend != "" {
+		mic.backend = backend
+	}
+	return mic
+}
+
+func (mic Microphone) Run(ctx context.Context) (*entity.Payload, error) {
+	var apps []string
+	var muted string
+	var err error
+	switch mic.backend {
+	case "alsa":
+		apps, err = mic.alsaCaptureApps()
+		if err != nil {
+			return nil, err
+		}
+		if out, amixerErr := mic.command(ctx, "amixer", "sget", "Capture"); amixerErr == nil {
+			muted = mic.processAmixerMute(out)
+		}
+	case "pactl":
+		out, pactlErr := mic.command(ctx, "pactl", "list", "source-outputs")
+		if pactlErr != nil {
+			return nil, pactlErr
+		}
+		apps = mic.processPactlSourceOutputs(out)
+		if out, pactlErr = mic.command(ctx, "pactl", "get-source-mute", "@DEFAULT_SOURCE@"); pactlErr == nil {
+			muted = mic.processPactlMute(out)
+		}
+	default:
+		return nil, fmt.Errorf("unknown backend for microphone: %s", mic.backend)
+	}
+
+	p := entity.NewPayload()
+	p.State = len(apps) > 0
+	p.Attributes["streams"] = len(apps)
+	p.Attributes["applications"] = uniqueSorted(apps)
+	if muted != "" {
+		p.Attributes["muted"] = muted
+	}
+	if len(apps) > 0 {
+		p.Icon = "mdi:microphone"
+	}
+	return p, nil
+}
+
+func (mic Microphone) command(ctx context.Context, name string, args ...string) (string, error) {
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return "", err
+	}
+	return out.String(), nil
+}
+
+// alsaCaptureApps returns the names of the processes owning a running ALSA capture substream.
+func (mic Microphone) alsaCaptureApps() ([]string, error) {
+	files, err := filepath.Glob(filepath.Join(mic.asoundRoot, "card*", "pcm*c", "sub*", "status"))
+	if err != nil {
+		return nil, err
+	}
+	if len(files) == 0 {
+		if _, statErr := os.Stat(mic.asoundRoot); statErr != nil {
+			return nil, fmt.Errorf("failed to read ALSA status: %w", statErr)
+		}
+	}
+	apps := make([]string, 0)
+	for _, file := range files {
+		b, err := os.ReadFile(file)
+		if err != nil {
+			continue
+		}
+		running, pid := mic.processALSAStatus(string(b))
+		if !running {
+			continue
+		}
+		name := "unknown"
+		if comm, err := os.ReadFile(filepath.Join(mic.procRoot, strconv.Itoa(pid), "comm")); err == nil {
+			name = strings.TrimSpace(string(comm))
+		}
+		apps = append(apps, name)
+	}
+	return apps, nil
+}
+
+// processALSAStatus parses a substream status file. A closed substream only contains "closed".
+func (mic Microphone) processALSAStatus(output string) (running bool, ownerPID int) {
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		match := reALSAStatusLine.FindStringSubmatch(strings.TrimSpace(scanner.Text()))
+		if match == nil {
+			continue
+		}
+		switch match[1] {
+		case "state":
+			running = match[2] == "RUNNING"
+		case "owner_pid":
+			ownerPID, _ = strconv.Atoi(match[2])
+		}
+	}
+	return running, ownerPID
+}
+
+// processPactlSourceOutputs returns the application names of all active recording streams.
+func (mic Microphone) processPactlSourceOutputs(output string) []string {
+	apps := make([]string, 0)
+	var name string
+	var corked, inStream bool
+	flush := func() {
+		if inStream && !corked {
+			if name == "" {
+				name = "unknown"
+			}
+			apps = append(apps, name)
+		}
+		name, corked = "", false
+	}
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if strings.HasPrefix(line, "Source Output #") {
+			flush()
+			inStream = true
+			continue
+		}
+		if line == "Corked: yes" {
+			corked = true
+			continue
+		}
+		if match := rePactlProperty.FindStringSubmatch(line); match != nil && match[1] == "application.name" {
+			name = match[2]
+		}
+	}
+	flush()
+	return apps
+}
+
+func (mic Microphone) processPactlMute(output string) string {
+	switch strings.TrimSpace(strings.TrimPrefix(strings.TrimSpace(output), "Mute:")) {
+	case "yes":
+		return "on"
+	case "no":
+		return "off"
+	}
+	return ""
+}
+
+func (mic Microphone) processAmixerMute(output string) string {
+	match := reAmixerCapture.FindStringSubmatch(output)
+	if match == nil {
+		return ""
+	}
+	// amixer returns "on" for an enabled device and "off" for a muted device.
+	if match[1] == "off" {
+		return "on"
+	}
+	return "off"
+}
+
+// uniqueSorted returns the sorted unique values of the given slice.
+func uniqueSorted(values []string) []string {
+	seen := make(map[string]bool, len(values))
+	unique := make([]string, 0, len(values))
+	for _, value := range values {
+		if seen[value] {
+			continue
+		}
+		seen[value] = true
+		unique = append(unique, value)
+	}
+	sort.Strings(unique)
+	return unique
+}
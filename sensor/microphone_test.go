@@ -0,0 +1,66 @@
+package sensor
+
+import (
+	"testing"
+
+	"hacompanion/entity"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestMicrophone_ALSAStatus(t *testing.T) {
+	input := `
+		state: RUNNING
+		owner_pid   : 4242
+		trigger_time: 1234.567890
+		tstamp      : 1240.123456
+		delay       : 256
+		avail       : 256
+		avail_max   : 512
+	`
+
+	mic := NewMicrophone(entity.Meta{})
+
+	running, pid := mic.processALSAStatus(input)
+	require.True(t, running)
+	require.Equal(t, 4242, pid)
+
+	running, _ = mic.processALSAStatus("closed\n")
+	require.False(t, running)
+}
+
+func TestMicrophone_PactlSourceOutputs(t *testing.T) {
+	input := `
+Source Output #12
+	Driver: PipeWire
+	Owner Module: n/a
+	Client: 80
+	Source: 55
+	Corked: no
+	Mute: no
+	Properties:
+		media.name = "RecordStream"
+		application.name = "Firefox"
+		application.process.binary = "firefox"
+
+Source Output #13
+	Driver: PipeWire
+	Client: 81
+	Corked: yes
+	Properties:
+		application.name = "Discord"
+
+Source Output #14
+	Driver: PipeWire
+	Client: 82
+	Corked: no
+	Properties:
+		application.name = "Zoom"
+`
+
+	mic := NewMicrophone(entity.Meta{"backend": "pactl"})
+
+	require.Equal(t, []string{"Firefox", "Zoom"}, mic.processPactlSourceOutputs(input))
+	require.Equal(t, "on", mic.processPactlMute("Mute: yes\n"))
+	require.Equal(t, "off", mic.processPactlMute("Mute: no\n"))
+}
@@ -4,86 +4,460 @@ import (
 	"context"
 	"errors"
 	"fmt"
+	"io"
+	"net"
 	"net/http"
+	"os"
 	"os/exec"
+	"regexp"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
 	"time"
 
 	"hacompanion/entity"
+	"hacompanion/util"
 )
 
+const (
+	onlinePolicyAny = "any"
+	onlinePolicyAll = "all"
+
+	// pingInterval is the time between two echo requests to the same target.
+	pingInterval = 200 * time.Millisecond
+	// onlineMaxBodySize limits the response body that is matched against the body regex.
+	onlineMaxBodySize = 1 << 20
+)
+
+var (
+	onlineModes = []string{"ping", "tcp", "dns", "http"}
+
+	rePingRTT  = regexp.MustCompile(`= ([\d.]+)/([\d.]+)/([\d.]+)`)
+	rePingSent = regexp.MustCompile(`(\d+) packets transmitted, (\d+) (?:packets )?received`)
+
+	errPingSocketUnavailable = errors.New("unprivileged ICMP sockets are not available")
+)
+
+// OnlineCheck reports if one or more targets are reachable using ping,
+// TCP connections, DNS lookups or HTTP requests.
 type OnlineCheck struct {
-	mode   string
-	target string
-	client http.Client
+	mode    string
+	targets []string
+	policy  string
+	count   int
+	timeout time.Duration
+	// expectedStatus contains the HTTP status codes that are considered online.
+	expectedStatus []int
+	bodyRegex      *regexp.Regexp
+	// resolver is the address of the DNS server used in dns mode.
+	resolver string
+	client   http.Client
+	// listenICMP opens an unprivileged ICMP socket, pingBinary is used if this is not permitted.
+	listenICMP func(ipv6 bool) (net.PacketConn, error)
+	pingBinary string
+	// err is set if the configuration is invalid and returned on every run.
+	err error
 }
 
 func NewOnlineCheck(m entity.Meta) *OnlineCheck {
 	o := OnlineCheck{
-		mode: "ping",
-		client: http.Client{
-			Timeout: 5 * time.Second,
-		},
+		mode:           "ping",
+		policy:         onlinePolicyAny,
+		count:          m.GetInt("count"),
+		expectedStatus: m.GetIntSlice("expected_status"),
+		resolver:       m.GetString("resolver"),
+		listenICMP:     listenICMP,
+		pingBinary:     "ping",
 	}
 	if mode := m.GetString("mode"); mode != "" {
 		o.mode = mode
 	}
-	if host := m.GetString("target"); host != "" {
-		o.target = host
+	if target := m.GetString("target"); target != "" {
+		o.targets = append(o.targets, target)
+	}
+	o.targets = append(o.targets, m.GetStringSlice("targets")...)
+	if policy := m.GetString("policy"); policy != "" {
+		o.policy = policy
+	}
+	if timeout, err := time.ParseDuration(m.GetString("timeout")); err == nil && timeout > 0 {
+		o.timeout = timeout
+	}
+	o.client = http.Client{Timeout: o.attemptTimeout("http")}
+	if expr := m.GetString("body_regex"); expr != "" {
+		o.bodyRegex, o.err = regexp.Compile(expr)
+	}
+	if o.policy != onlinePolicyAny && o.policy != onlinePolicyAll {
+		o.err = fmt.Errorf("unknown policy for online check: %s", o.policy)
+	}
+	for _, target := range o.targets {
+		if mode, _ := o.targetMode(target); !slices.Contains(onlineModes, mode) {
+			o.err = fmt.Errorf("unknown mode for online check: %s", mode)
+		}
 	}
 	return &o
 }
 
+// probeStats contains the round-trip times of a target.
+type probeStats struct {
+	sent     int
+	received int
+	min      time.Duration
+	max      time.Duration
+	total    time.Duration
+}
+
+func (s *probeStats) add(rtt time.Duration) {
+	if s.received == 0 || rtt < s.min {
+		s.min = rtt
+	}
+	if rtt > s.max {
+		s.max = rtt
+	}
+	s.received++
+	s.total += rtt
+}
+
+// probeResult is the result of checking a single target.
+type probeResult struct {
+	target string
+	mode   string
+	stats  probeStats
+	// detail is the HTTP status or the resolved address of the last attempt.
+	detail string
+	err    error
+}
+
+func (r probeResult) reachable() bool {
+	return r.stats.received > 0
+}
+
 func (o OnlineCheck) Run(ctx context.Context) (*entity.Payload, error) {
-	if o.target == "" {
+	if o.err != nil {
+		return nil, o.err
+	}
+	if len(o.targets) == 0 {
 		return nil, fmt.Errorf("online check requires target to be specified")
 	}
-	switch o.mode {
-	case "http":
-		return o.checkHTTP(ctx)
-	case "ping":
-		return o.checkPing(ctx)
+	results := make([]probeResult, len(o.targets))
+	var wg sync.WaitGroup
+	for i, target := range o.targets {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			results[i] = o.probe(ctx, target)
+		}()
+	}
+	wg.Wait()
+	return o.process(results), nil
+}
+
+// targetMode returns the mode and the address of a target. The mode can be
+// set per target using a scheme like tcp://, otherwise the configured mode is used.
+func (o OnlineCheck) targetMode(target string) (mode, address string) {
+	scheme, rest, ok := strings.Cut(target, "://")
+	if !ok {
+		return o.mode, target
+	}
+	switch scheme {
+	case "http", "https":
+		return "http", target
 	default:
-		return nil, fmt.Errorf("unknown mode for online check: %s", o.mode)
+		return scheme, rest
 	}
 }
 
-func (o OnlineCheck) checkHTTP(ctx context.Context) (*entity.Payload, error) {
-	p := entity.NewPayload()
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, o.target, nil)
+// attemptTimeout returns the timeout of a single attempt of the given mode.
+func (o OnlineCheck) attemptTimeout(mode string) time.Duration {
+	switch {
+	case o.timeout > 0:
+		return o.timeout
+	case mode == "http":
+		return 5 * time.Second
+	}
+	return 2 * time.Second
+}
+
+func (o OnlineCheck) probe(ctx context.Context, target string) probeResult {
+	mode, address := o.targetMode(target)
+	result := probeResult{target: target, mode: mode}
+	count := o.count
+	if count <= 0 {
+		// Packet loss is only meaningful for ping, other modes use a single attempt by default.
+		count = 1
+		if mode == "ping" {
+			count = 3
+		}
+	}
+	if mode == "ping" {
+		result.stats, result.err = o.ping(ctx, address, count)
+		return result
+	}
+
+	var attempt func(ctx context.Context, address string) (string, error)
+	switch mode {
+	case "tcp":
+		attempt = o.checkTCP
+	case "dns":
+		attempt = o.checkDNS
+	case "http":
+		attempt = o.checkHTTP
+	}
+	for i := 0; i < count && ctx.Err() == nil; i++ {
+		attemptCtx, cancel := context.WithTimeout(ctx, o.attemptTimeout(mode))
+		start := time.Now()
+		detail, err := attempt(attemptCtx, address)
+		rtt := time.Since(start)
+		cancel()
+		result.stats.sent++
+		if detail != "" {
+			result.detail = detail
+		}
+		if err != nil {
+			result.err = err
+			continue
+		}
+		result.stats.add(rtt)
+	}
+	return result
+}
+
+func (o OnlineCheck) checkTCP(ctx context.Context, address string) (string, error) {
+	var d net.Dialer
+	conn, err := d.DialContext(ctx, "tcp", address)
 	if err != nil {
-		return nil, err
+		return "", err
+	}
+	return "", conn.Close()
+}
+
+func (o OnlineCheck) checkDNS(ctx context.Context, name string) (string, error) {
+	resolver := net.DefaultResolver
+	if o.resolver != "" {
+		resolver = &net.Resolver{
+			PreferGo: true,
+			Dial: func(ctx context.Context, network, _ string) (net.Conn, error) {
+				var d net.Dialer
+				return d.DialContext(ctx, network, o.resolver)
+			},
+		}
+	}
+	addresses, err := resolver.LookupHost(ctx, name)
+	if err != nil {
+		return "", err
+	}
+	if len(addresses) == 0 {
+		return "", fmt.Errorf("%s did not resolve to any address", name)
+	}
+	return addresses[0], nil
+}
+
+func (o OnlineCheck) checkHTTP(ctx context.Context, url string) (string, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return "", err
 	}
 	req.Header.Set("User-Agent", "HomeAssistant-Companion/Online-Check")
 
 	resp, err := o.client.Do(req)
 	if err != nil {
-		p.State = false
-		p.Attributes["err"] = err.Error()
-		return p, nil
+		return "", err
 	}
-
 	defer resp.Body.Close()
 
-	p.State = true
-	p.Attributes["status"] = resp.Status
+	if len(o.expectedStatus) > 0 {
+		if !slices.Contains(o.expectedStatus, resp.StatusCode) {
+			return resp.Status, fmt.Errorf("unexpected status %s", resp.Status)
+		}
+	} else if resp.StatusCode >= http.StatusBadRequest {
+		return resp.Status, fmt.Errorf("unexpected status %s", resp.Status)
+	}
+	if o.bodyRegex != nil {
+		body, err := io.ReadAll(io.LimitReader(resp.Body, onlineMaxBodySize))
+		if err != nil {
+			return resp.Status, err
+		}
+		if !o.bodyRegex.Match(body) {
+			return resp.Status, fmt.Errorf("response does not match %s", o.bodyRegex)
+		}
+	}
+	return resp.Status, nil
+}
 
-	return p, nil
+// ping sends ICMP echo requests using an unprivileged ICMP socket.
+// If these are not permitted, the ping command is used instead.
+func (o OnlineCheck) ping(ctx context.Context, host string, count int) (probeStats, error) {
+	stats, err := o.pingSocket(ctx, host, count)
+	if errors.Is(err, errPingSocketUnavailable) {
+		return o.pingCommand(ctx, host, count)
+	}
+	return stats, err
 }
 
-func (o OnlineCheck) checkPing(ctx context.Context) (*entity.Payload, error) {
-	p := entity.NewPayload()
-	//nolint:gosec
-	cmd := exec.CommandContext(ctx, "ping", "-c 2", "-w 4", o.target)
-	err := cmd.Run()
+func (o OnlineCheck) pingSocket(ctx context.Context, host string, count int) (probeStats, error) {
+	var stats probeStats
+	addresses, err := net.DefaultResolver.LookupIPAddr(ctx, host)
 	if err != nil {
-		p.State = false
-		var exitErr *exec.ExitError
-		if errors.As(err, &exitErr) && exitErr.ExitCode() == 1 {
-			p.Attributes["err"] = fmt.Sprintf("could not reach %s", o.target)
+		return stats, err
+	}
+	ip := addresses[0].IP
+	echoRequest, echoReply := byte(8), byte(0)
+	if ip.To4() == nil {
+		echoRequest, echoReply = 128, 129
+	}
+	conn, err := o.listenICMP(ip.To4() == nil)
+	if err != nil {
+		return stats, fmt.Errorf("%w: %w", errPingSocketUnavailable, err)
+	}
+	defer conn.Close()
+
+	var lastErr error
+	buf := make([]byte, 1500)
+	for seq := 1; seq <= count && ctx.Err() == nil; seq++ {
+		if seq > 1 {
+			select {
+			case <-time.After(pingInterval):
+			case <-ctx.Done():
+				return stats, ctx.Err()
+			}
+		}
+		// The kernel sets the identifier and the checksum of the echo request.
+		msg := []byte{echoRequest, 0, 0, 0, 0, 0, byte(seq >> 8), byte(seq), 'h', 'a', 'c', 'o', 'm', 'p'}
+		start := time.Now()
+		stats.sent++
+		if _, err = conn.WriteTo(msg, &net.UDPAddr{IP: ip}); err != nil {
+			lastErr = err
+			continue
+		}
+		if err = conn.SetReadDeadline(start.Add(o.attemptTimeout("ping"))); err != nil {
+			return stats, err
 		}
-		return p, nil
+		for {
+			n, _, err := conn.ReadFrom(buf)
+			if err != nil {
+				lastErr = fmt.Errorf("no reply from %s", host)
+				break
+			}
+			if n >= 8 && buf[0] == echoReply && int(buf[6])<<8|int(buf[7]) == seq {
+				stats.add(time.Since(start))
+				break
+			}
+		}
+	}
+	if stats.received > 0 {
+		return stats, nil
 	}
+	return stats, lastErr
+}
 
-	p.State = true
-	return p, nil
+// listenICMP opens an unprivileged ICMP socket. These are allowed for
+// the groups in net.ipv4.ping_group_range.
+func listenICMP(ipv6 bool) (net.PacketConn, error) {
+	family, proto := syscall.AF_INET, syscall.IPPROTO_ICMP
+	if ipv6 {
+		family, proto = syscall.AF_INET6, syscall.IPPROTO_ICMPV6
+	}
+	fd, err := syscall.Socket(family, syscall.SOCK_DGRAM|syscall.SOCK_CLOEXEC|syscall.SOCK_NONBLOCK, proto)
+	if err != nil {
+		return nil, err
+	}
+	file := os.NewFile(uintptr(fd), "icmp")
+	defer file.Close()
+	return net.FilePacketConn(file)
+}
+
+func (o OnlineCheck) pingCommand(ctx context.Context, host string, count int) (probeStats, error) {
+	timeout := strconv.Itoa(int(o.attemptTimeout("ping").Seconds()))
+	//nolint:gosec
+	cmd := exec.CommandContext(ctx, o.pingBinary, "-c", strconv.Itoa(count), "-W", timeout, host)
+	out, err := cmd.Output()
+	stats := o.processPingOutput(string(out))
+	if stats.received > 0 {
+		return stats, nil
+	}
+	var exitErr *exec.ExitError
+	if err == nil || (errors.As(err, &exitErr) && exitErr.ExitCode() == 1) {
+		return stats, fmt.Errorf("could not reach %s", host)
+	}
+	return stats, err
+}
+
+// processPingOutput parses the summary of the ping command.
+func (o OnlineCheck) processPingOutput(output string) probeStats {
+	var stats probeStats
+	if match := rePingSent.FindStringSubmatch(output); match != nil {
+		stats.sent, _ = strconv.Atoi(match[1])
+		stats.received, _ = strconv.Atoi(match[2])
+	}
+	if match := rePingRTT.FindStringSubmatch(output); match != nil && stats.received > 0 {
+		parse := func(ms string) time.Duration {
+			value, _ := strconv.ParseFloat(ms, 64)
+			return time.Duration(value * float64(time.Millisecond))
+		}
+		stats.min = parse(match[1])
+		stats.total = parse(match[2]) * time.Duration(stats.received)
+		stats.max = parse(match[3])
+	}
+	return stats
+}
+
+func (o OnlineCheck) process(results []probeResult) *entity.Payload {
+	p := entity.NewPayload()
+	var reachable int
+	targets := make([]map[string]interface{}, 0, len(results))
+	for _, result := range results {
+		if result.reachable() {
+			reachable++
+		}
+		targets = append(targets, o.resultAttributes(result))
+	}
+	if o.policy == onlinePolicyAll {
+		p.State = reachable == len(results)
+	} else {
+		p.State = reachable > 0
+	}
+	// A single target is reported directly, multiple targets as a list.
+	if len(results) == 1 {
+		for key, value := range targets[0] {
+			p.Attributes[key] = value
+		}
+		return p
+	}
+	p.Attributes["policy"] = o.policy
+	p.Attributes["reachable"] = reachable
+	p.Attributes["targets"] = targets
+	return p
+}
+
+func (o OnlineCheck) resultAttributes(result probeResult) map[string]interface{} {
+	ms := func(d time.Duration) float64 {
+		return util.RoundToTwoDecimals(float64(d) / float64(time.Millisecond))
+	}
+	attributes := map[string]interface{}{
+		"target":    result.target,
+		"mode":      result.mode,
+		"reachable": result.reachable(),
+	}
+	if result.stats.sent > 0 {
+		lost := result.stats.sent - result.stats.received
+		attributes["packet_loss"] = util.RoundToTwoDecimals(float64(lost) / float64(result.stats.sent) * 100)
+	}
+	if result.reachable() {
+		attributes["rtt_min"] = ms(result.stats.min)
+		attributes["rtt_avg"] = ms(result.stats.total / time.Duration(result.stats.received))
+		attributes["rtt_max"] = ms(result.stats.max)
+	}
+	if result.detail != "" {
+		switch result.mode {
+		case "http":
+			attributes["status"] = result.detail
+		case "dns":
+			attributes["address"] = result.detail
+		}
+	}
+	if result.err != nil && !result.reachable() {
+		attributes["err"] = result.err.Error()
+	}
+	return attributes
 }
@@ -0,0 +1,243 @@
+package sensor
+
+import (
+	"context"
+	"errors"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"hacompanion/entity"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOnlineCheckHTTP(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/error":
+			w.WriteHeader(http.StatusInternalServerError)
+		case "/created":
+			w.WriteHeader(http.StatusCreated)
+		}
+		_, _ = w.Write([]byte(`{"status":"ok"}`))
+	}))
+	defer server.Close()
+
+	cases := []struct {
+		name   string
+		meta   entity.Meta
+		online bool
+		err    string
+	}{
+		{"ok", entity.Meta{"target": server.URL}, true, ""},
+		{"server error", entity.Meta{"target": server.URL + "/error"}, false, "unexpected status 500 Internal Server Error"},
+		{"expected status", entity.Meta{"target": server.URL + "/created", "expected_status": []interface{}{int64(200)}}, false, "unexpected status 201 Created"},
+		{"body regex", entity.Meta{"target": server.URL, "body_regex": `"status":"ok"`}, true, ""},
+		{"body mismatch", entity.Meta{"target": server.URL, "body_regex": `"status":"degraded"`}, false, `response does not match "status":"degraded"`},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			tc.meta["mode"] = "http"
+			p, err := NewOnlineCheck(tc.meta).Run(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, tc.online, p.State)
+			require.Equal(t, "http", p.Attributes["mode"])
+			require.Contains(t, p.Attributes, "status")
+			if tc.err != "" {
+				require.Equal(t, tc.err, p.Attributes["err"])
+				require.Equal(t, 100.0, p.Attributes["packet_loss"])
+			} else {
+				require.Equal(t, 0.0, p.Attributes["packet_loss"])
+				require.Contains(t, p.Attributes, "rtt_avg")
+			}
+		})
+	}
+}
+
+func TestOnlineCheckTCPAndDNS(t *testing.T) {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	go func() {
+		for {
+			conn, err := listener.Accept()
+			if err != nil {
+				return
+			}
+			conn.Close()
+		}
+	}()
+	// A closed port to simulate an unreachable target.
+	closed, err := net.Listen("tcp", "127.0.0.1:0")
+	require.NoError(t, err)
+	closedAddr := closed.Addr().String()
+	closed.Close()
+	defer listener.Close()
+
+	o := NewOnlineCheck(entity.Meta{
+		"mode":    "tcp",
+		"targets": []interface{}{listener.Addr().String(), "dns://localhost", "tcp://" + closedAddr},
+		"count":   int64(2),
+		"timeout": "1s",
+	})
+	p, err := o.Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, true, p.State)
+	require.Equal(t, 2, p.Attributes["reachable"])
+	targets := p.Attributes["targets"].([]map[string]interface{})
+	require.Len(t, targets, 3)
+	require.Equal(t, true, targets[0]["reachable"])
+	require.Equal(t, 0.0, targets[0]["packet_loss"])
+	require.Equal(t, "dns", targets[1]["mode"])
+	require.Equal(t, true, targets[1]["reachable"])
+	require.Contains(t, targets[1], "address")
+	require.Equal(t, false, targets[2]["reachable"])
+	require.Equal(t, 100.0, targets[2]["packet_loss"])
+
+	o.policy = onlinePolicyAll
+	p, err = o.Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, false, p.State)
+}
+
+func TestOnlineCheckProcess(t *testing.T) {
+	reachable := probeResult{target: "192.168.1.1", mode: "ping"}
+	reachable.stats.sent = 4
+	for _, rtt := range []time.Duration{2 * time.Millisecond, 4 * time.Millisecond, 3 * time.Millisecond} {
+		reachable.stats.add(rtt)
+	}
+	unreachable := probeResult{target: "8.8.8.8", mode: "ping", stats: probeStats{sent: 3}, err: errors.New("no reply from 8.8.8.8")}
+
+	p := NewOnlineCheck(entity.Meta{}).process([]probeResult{reachable})
+	require.EqualValues(t, map[string]interface{}{
+		"target":      "192.168.1.1",
+		"mode":        "ping",
+		"reachable":   true,
+		"packet_loss": 25.0,
+		"rtt_min":     2.0,
+		"rtt_avg":     3.0,
+		"rtt_max":     4.0,
+	}, p.Attributes)
+	require.Equal(t, true, p.State)
+
+	p = NewOnlineCheck(entity.Meta{"policy": "all"}).process([]probeResult{reachable, unreachable})
+	require.Equal(t, false, p.State)
+	require.Equal(t, 1, p.Attributes["reachable"])
+	require.Equal(t, "all", p.Attributes["policy"])
+	require.Equal(t, "no reply from 8.8.8.8", p.Attributes["targets"].([]map[string]interface{})[1]["err"])
+}
+
+func TestOnlineCheckPingOutput(t *testing.T) {
+	output := `PING 192.168.1.1 (192.168.1.1) 56(84) bytes of data.
+64 bytes from 192.168.1.1: icmp_seq=1 ttl=64 time=1.52 ms
+64 bytes from 192.168.1.1: icmp_seq=3 ttl=64 time=2.48 ms
+
+--- 192.168.1.1 ping statistics ---
+3 packets transmitted, 2 received, 33.3333% packet loss, time 2003ms
+rtt min/avg/max/mdev = 1.520/2.000/2.480/0.480 ms
+`
+	stats := NewOnlineCheck(entity.Meta{}).processPingOutput(output)
+	require.Equal(t, probeStats{
+		sent:     3,
+		received: 2,
+		min:      1520 * time.Microsecond,
+		max:      2480 * time.Microsecond,
+		total:    4 * time.Millisecond,
+	}, stats)
+}
+
+func TestOnlineCheckConfig(t *testing.T) {
+	_, err := NewOnlineCheck(entity.Meta{}).Run(context.Background())
+	require.Error(t, err)
+	_, err = NewOnlineCheck(entity.Meta{"target": "udp://1.1.1.1"}).Run(context.Background())
+	require.EqualError(t, err, "unknown mode for online check: udp")
+	_, err = NewOnlineCheck(entity.Meta{"target": "1.1.1.1", "policy": "most"}).Run(context.Background())
+	require.EqualError(t, err, "unknown policy for online check: most")
+}
+
+// fakeICMPConn answers echo requests like an unprivileged ICMP socket, if reply is set.
+type fakeICMPConn struct {
+	net.PacketConn
+	reply    bool
+	requests chan []byte
+	deadline time.Time
+}
+
+func newFakeICMPConn(reply bool) *fakeICMPConn {
+	return &fakeICMPConn{reply: reply, requests: make(chan []byte, 10)}
+}
+
+func (c *fakeICMPConn) WriteTo(b []byte, _ net.Addr) (int, error) {
+	c.requests <- append([]byte(nil), b...)
+	return len(b), nil
+}
+
+func (c *fakeICMPConn) ReadFrom(b []byte) (int, net.Addr, error) {
+	timeout := time.After(time.Until(c.deadline))
+	for {
+		select {
+		case request := <-c.requests:
+			if !c.reply {
+				continue
+			}
+			n := copy(b, request)
+			b[0] = 0
+			return n, &net.UDPAddr{IP: net.IPv4(127, 0, 0, 1)}, nil
+		case <-timeout:
+			return 0, nil, errors.New("i/o timeout")
+		}
+	}
+}
+
+func (c *fakeICMPConn) SetReadDeadline(t time.Time) error {
+	c.deadline = t
+	return nil
+}
+
+func (c *fakeICMPConn) Close() error { return nil }
+
+func TestOnlineCheckPingSocket(t *testing.T) {
+	o := NewOnlineCheck(entity.Meta{"target": "127.0.0.1", "count": int64(2), "timeout": "50ms"})
+	o.listenICMP = func(ipv6 bool) (net.PacketConn, error) {
+		require.False(t, ipv6)
+		return newFakeICMPConn(true), nil
+	}
+	stats, err := o.ping(context.Background(), "127.0.0.1", 2)
+	require.NoError(t, err)
+	require.Equal(t, 2, stats.sent)
+	require.Equal(t, 2, stats.received)
+
+	// Requests without a reply time out.
+	o.listenICMP = func(bool) (net.PacketConn, error) { return newFakeICMPConn(false), nil }
+	stats, err = o.ping(context.Background(), "127.0.0.1", 2)
+	require.EqualError(t, err, "no reply from 127.0.0.1")
+	require.Equal(t, 2, stats.sent)
+	require.Equal(t, 0, stats.received)
+}
+
+func TestOnlineCheckPingFallback(t *testing.T) {
+	// The ping command is replaced with a script that prints the summary of a successful ping.
+	script := filepath.Join(t.TempDir(), "ping")
+	output := "2 packets transmitted, 2 received, 0% packet loss, time 1001ms\nrtt min/avg/max/mdev = 1.000/1.500/2.000/0.500 ms\n"
+	require.NoError(t, os.WriteFile(script, []byte("#!/bin/sh\ncat <<EOF\n"+output+"EOF\n"), 0o700))
+
+	o := NewOnlineCheck(entity.Meta{"target": "127.0.0.1"})
+	o.pingBinary = script
+	// Without permission for ICMP sockets by net.ipv4.ping_group_range, the ping command is used.
+	o.listenICMP = func(bool) (net.PacketConn, error) { return nil, syscall.EACCES }
+	stats, err := o.ping(context.Background(), "127.0.0.1", 2)
+	require.NoError(t, err)
+	require.Equal(t, 2, stats.received)
+	require.Equal(t, time.Millisecond, stats.min)
+	require.Equal(t, 2*time.Millisecond, stats.max)
+
+	// Other errors are not hidden by the fallback.
+	o.listenICMP = func(bool) (net.PacketConn, error) { return newFakeICMPConn(true), nil }
+	_, err = o.ping(context.Background(), "invalid host name.", 1)
+	require.Error(t, err)
+}
@@ -0,0 +1,182 @@
+package sensor
+
+import (
+	"bufio"
+	"context"
+	"os"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"syscall"
+
+	"hacompanion/entity"
+	"hacompanion/util"
+)
+
+// OSInfo reports the operating system and hardware of this machine.
+type OSInfo struct {
+	root string
+}
+
+func NewOSInfo() *OSInfo {
+	return &OSInfo{root: "/"}
+}
+
+func (o OSInfo) Run(ctx context.Context) (*entity.Payload, error) {
+	p := entity.NewPayload()
+
+	release := o.osRelease()
+	p.State = release["PRETTY_NAME"]
+	if p.State == "" {
+		p.State = runtime.GOOS
+	}
+	o.setAttribute(p, "os_name", release["NAME"])
+	o.setAttribute(p, "os_id", release["ID"])
+	o.setAttribute(p, "os_version", release["VERSION_ID"])
+
+	var uname syscall.Utsname
+	if err := syscall.Uname(&uname); err == nil {
+		o.setAttribute(p, "kernel", utsnameToString(uname.Release[:]))
+		o.setAttribute(p, "architecture", utsnameToString(uname.Machine[:]))
+	}
+
+	manufacturer, model := o.deviceModel()
+	o.setAttribute(p, "manufacturer", manufacturer)
+	o.setAttribute(p, "model", model)
+	o.setAttribute(p, "product_version", o.dmi("product_version"))
+	o.setAttribute(p, "board", strings.TrimSpace(o.dmi("board_vendor")+" "+o.dmi("board_name")))
+	o.setAttribute(p, "bios_version", o.dmi("bios_version"))
+
+	cpuModel, cores := o.cpuInfo()
+	o.setAttribute(p, "cpu_model", cpuModel)
+	if cores > 0 {
+		p.Attributes["cpu_cores"] = cores
+	}
+	if total := o.memoryTotal(); total > 0 {
+		p.Attributes["memory_total"] = total
+	}
+
+	o.setAttribute(p, "session_type", os.Getenv("XDG_SESSION_TYPE"))
+	o.setAttribute(p, "desktop", os.Getenv("XDG_CURRENT_DESKTOP"))
+
+	if cmdline := o.read("proc/cmdline"); cmdline != "" {
+		p.Attributes["kernel_cmdline"] = strings.Fields(cmdline)
+	}
+	return p, nil
+}
+
+func (o OSInfo) setAttribute(p *entity.Payload, name, value string) {
+	if value != "" {
+		p.Attributes[name] = value
+	}
+}
+
+// read returns the trimmed content of a file relative to the root directory.
+func (o OSInfo) read(path string) string {
+	b, err := os.ReadFile(filepath.Join(o.root, path))
+	if err != nil {
+		return ""
+	}
+	return strings.TrimSpace(string(b))
+}
+
+// dmi returns a value from the DMI tables. Placeholder values
+// that are left by many vendors are treated as unknown.
+func (o OSInfo) dmi(name string) string {
+	value := o.read(filepath.Join("sys/class/dmi/id", name))
+	switch strings.ToLower(value) {
+	case "to be filled by o.e.m.", "default string", "system product name", "system manufacturer", "not applicable":
+		return ""
+	}
+	return value
+}
+
+// osRelease parses the os-release file.
+func (o OSInfo) osRelease() map[string]string {
+	release := make(map[string]string)
+	content := o.read("etc/os-release")
+	if content == "" {
+		content = o.read("usr/lib/os-release")
+	}
+	scanner := bufio.NewScanner(strings.NewReader(content))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(strings.TrimSpace(scanner.Text()), "=")
+		if !ok || strings.HasPrefix(key, "#") {
+			continue
+		}
+		if unquoted, err := strconv.Unquote(value); err == nil {
+			value = unquoted
+		} else {
+			value = strings.Trim(value, "'")
+		}
+		release[key] = value
+	}
+	return release
+}
+
+// deviceModel returns the manufacturer and model of the machine from the DMI
+// tables. Devices without DMI, like most ARM boards, use the device tree model.
+func (o OSInfo) deviceModel() (manufacturer, model string) {
+	manufacturer = o.dmi("sys_vendor")
+	model = o.dmi("product_name")
+	if model == "" {
+		model = strings.TrimRight(o.read("proc/device-tree/model"), "\x00")
+	}
+	return manufacturer, model
+}
+
+// cpuInfo returns the CPU model and the number of logical cores.
+func (o OSInfo) cpuInfo() (model string, cores int) {
+	scanner := bufio.NewScanner(strings.NewReader(o.read("proc/cpuinfo")))
+	for scanner.Scan() {
+		key, value, ok := strings.Cut(scanner.Text(), ":")
+		if !ok {
+			continue
+		}
+		key, value = strings.TrimSpace(key), strings.TrimSpace(value)
+		switch key {
+		case "processor":
+			cores++
+		case "model name", "Model", "Hardware":
+			if model == "" {
+				model = value
+			}
+		}
+	}
+	return model, cores
+}
+
+// memoryTotal returns the total memory in MB.
+func (o OSInfo) memoryTotal() float64 {
+	for _, match := range reMemory.FindAllStringSubmatch(o.read("proc/meminfo"), -1) {
+		if len(match) != 3 || strings.TrimSpace(match[1]) != "MemTotal" {
+			continue
+		}
+		kb, err := strconv.Atoi(strings.TrimSpace(match[2]))
+		if err != nil {
+			return 0
+		}
+		return util.RoundToTwoDecimals(float64(kb) / 1024)
+	}
+	return 0
+}
+
+// DeviceModel returns the manufacturer and model of this machine.
+// Empty values are returned if they are unknown.
+func DeviceModel() (manufacturer, model string) {
+	return NewOSInfo().deviceModel()
+}
+
+// utsnameToString converts a null-terminated uname field. Depending
+// on the architecture, the fields are either int8 or uint8 arrays.
+func utsnameToString[T int8 | uint8](field []T) string {
+	b := make([]byte, 0, len(field))
+	for _, c := range field {
+		if c == 0 {
+			break
+		}
+		b = append(b, byte(c))
+	}
+	return string(b)
+}
@@ -0,0 +1,57 @@
+package sensor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestOSInfo(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"etc/os-release": `NAME="Fedora Linux"
+VERSION_ID=40
+ID=fedora
+PRETTY_NAME="Fedora Linux 40 (Workstation Edition)"
+`,
+		"sys/class/dmi/id/sys_vendor":   "LENOVO\n",
+		"sys/class/dmi/id/product_name": "21CB0059GE\n",
+		"sys/class/dmi/id/board_vendor": "LENOVO\n",
+		"sys/class/dmi/id/board_name":   "To be filled by O.E.M.\n",
+		"proc/cpuinfo": `processor	: 0
+model name	: 12th Gen Intel(R) Core(TM) i7-1260P
+
+processor	: 1
+model name	: 12th Gen Intel(R) Core(TM) i7-1260P
+`,
+		"proc/meminfo": "MemTotal:       16279032 kB\nMemFree:          479256 kB\n",
+		"proc/cmdline": "BOOT_IMAGE=(hd0,gpt2)/vmlinuz-6.9.7 root=UUID=1234 ro rhgb quiet mitigations=off\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		require.NoError(t, os.MkdirAll(filepath.Dir(path), 0o755))
+		require.NoError(t, os.WriteFile(path, []byte(content), 0o600))
+	}
+	t.Setenv("XDG_SESSION_TYPE", "wayland")
+	t.Setenv("XDG_CURRENT_DESKTOP", "GNOME")
+
+	o := NewOSInfo()
+	o.root = root
+
+	res, err := o.Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "Fedora Linux 40 (Workstation Edition)", res.State)
+	require.Equal(t, "40", res.Attributes["os_version"])
+	require.Equal(t, "LENOVO", res.Attributes["manufacturer"])
+	require.Equal(t, "21CB0059GE", res.Attributes["model"])
+	require.Equal(t, "LENOVO", res.Attributes["board"])
+	require.Equal(t, "12th Gen Intel(R) Core(TM) i7-1260P", res.Attributes["cpu_model"])
+	require.Equal(t, 2, res.Attributes["cpu_cores"])
+	require.Equal(t, 15897.49, res.Attributes["memory_total"])
+	require.Equal(t, "wayland", res.Attributes["session_type"])
+	require.Equal(t, "GNOME", res.Attributes["desktop"])
+	require.Contains(t, res.Attributes["kernel_cmdline"], "mitigations=off")
+}
@@ -0,0 +1,195 @@
+package sensor
+
+import (
+	"context"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"hacompanion/entity"
+	"hacompanion/util"
+)
+
+// raplReadingMaxAge is the time a reading of the RAPL energy counters is shared
+// between the package_power and package_energy sensors of a single update.
+const raplReadingMaxAge = 2 * time.Second
+
+// raplCounters is the RAPL reader shared by all sensors.
+var raplCounters = newRAPLReader("/sys/class/powercap")
+
+// PackagePower reports the power draw of the CPU packages and their domains from the
+// RAPL energy counters. It reports the energy consumed by the packages instead
+// of the power draw if energy is set.
+type PackagePower struct {
+	reader *raplReader
+	energy bool
+}
+
+func NewPackagePower(energy bool) *PackagePower {
+	return &PackagePower{reader: raplCounters, energy: energy}
+}
+
+// raplDomain contains the energy counter of a single RAPL domain in µJ.
+type raplDomain struct {
+	path     string
+	name     string
+	energy   uint64
+	maxRange uint64
+}
+
+// raplReading contains the counters of all domains. power contains the power draw in W
+// per domain type since the previous reading, it is nil for the first reading.
+// energy is the energy in µJ consumed by the packages since the first reading.
+type raplReading struct {
+	domains []raplDomain
+	power   map[string]float64
+	energy  uint64
+}
+
+// raplReader caches the RAPL energy counters and calculates the power draw between two readings.
+type raplReader struct {
+	root string
+	now  func() time.Time
+
+	mu      sync.Mutex
+	reading raplReading
+	takenAt time.Time
+	energy  uint64
+}
+
+func newRAPLReader(root string) *raplReader {
+	return &raplReader{root: root, now: time.Now}
+}
+
+func (p *PackagePower) Run(ctx context.Context) (*entity.Payload, error) {
+	reading, err := p.reader.read()
+	if err != nil {
+		return nil, err
+	}
+	return p.process(reading), nil
+}
+
+// read returns the current counters. They are only read again if the cached reading is outdated.
+func (r *raplReader) read() (raplReading, error) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	now := r.now()
+	if !r.takenAt.IsZero() && now.Sub(r.takenAt) < raplReadingMaxAge {
+		return r.reading, nil
+	}
+	domains, err := r.readDomains()
+	if err != nil {
+		return raplReading{}, err
+	}
+	reading := raplReading{domains: domains}
+	if !r.takenAt.IsZero() {
+		deltas := r.deltas(domains)
+		reading.power = make(map[string]float64, len(deltas))
+		if elapsed := now.Sub(r.takenAt).Seconds(); elapsed > 0 {
+			for name, delta := range deltas {
+				reading.power[name] = float64(delta) / 1e6 / elapsed
+			}
+		}
+		// The energy only adds up the deltas, so it does not drop when a counter wraps around.
+		r.energy += deltas["package"]
+	}
+	reading.energy = r.energy
+	r.reading = reading
+	r.takenAt = now
+	return reading, nil
+}
+
+// deltas calculates the energy in µJ per domain type consumed since the last reading.
+func (r *raplReader) deltas(domains []raplDomain) map[string]uint64 {
+	previous := make(map[string]uint64, len(r.reading.domains))
+	for _, domain := range r.reading.domains {
+		previous[domain.path] = domain.energy
+	}
+	deltas := make(map[string]uint64)
+	for _, domain := range domains {
+		last, ok := previous[domain.path]
+		if !ok {
+			continue
+		}
+		delta := domain.energy - last
+		if domain.energy < last {
+			// The counter wrapped around after reaching its maximum.
+			delta = domain.energy
+			if domain.maxRange > last {
+				delta = domain.maxRange - last + domain.energy
+			}
+		}
+		// Multiple packages are summed up, e.g. package-0 and package-1.
+		name, _, _ := strings.Cut(domain.name, "-")
+		deltas[name] += delta
+	}
+	return deltas
+}
+
+func (r *raplReader) readDomains() ([]raplDomain, error) {
+	// Only the MSR interface is used, the MMIO interface reports the same package again.
+	paths, err := filepath.Glob(filepath.Join(r.root, "intel-rapl:*"))
+	if err != nil {
+		return nil, err
+	}
+	var domains []raplDomain
+	for _, path := range paths {
+		read := func(name string) string {
+			b, err := os.ReadFile(filepath.Join(path, name))
+			if err != nil {
+				return ""
+			}
+			return strings.TrimSpace(string(b))
+		}
+		b, err := os.ReadFile(filepath.Join(path, "energy_uj"))
+		if os.IsPermission(err) {
+			// Since Linux 5.10, the energy counters are only readable by root.
+			return nil, fmt.Errorf("failed to read RAPL energy counter, root permissions are required: %w", err)
+		}
+		energy, err := strconv.ParseUint(strings.TrimSpace(string(b)), 10, 64)
+		if err != nil {
+			continue
+		}
+		maxRange, _ := strconv.ParseUint(read("max_energy_range_uj"), 10, 64)
+		domains = append(domains, raplDomain{
+			path:     path,
+			name:     read("name"),
+			energy:   energy,
+			maxRange: maxRange,
+		})
+	}
+	if len(domains) == 0 {
+		return nil, fmt.Errorf("no readable RAPL energy counters found in %s", r.root)
+	}
+	return domains, nil
+}
+
+func (p *PackagePower) process(reading raplReading) *entity.Payload {
+	// Energy is reported in kWh with a higher precision, as the values are small.
+	kWh := math.Round(float64(reading.energy)/1e6/3.6e6*1e6) / 1e6
+
+	payload := entity.NewPayload()
+	if p.energy {
+		payload.State = kWh
+		return payload
+	}
+	// The power draw is unknown until two readings are available.
+	if reading.power == nil {
+		return nil
+	}
+	for name, value := range reading.power {
+		payload.Attributes[name] = util.RoundToTwoDecimals(value)
+	}
+	if total, ok := reading.power["package"]; ok {
+		payload.State = util.RoundToTwoDecimals(total)
+	} else {
+		payload.State = "unavailable"
+	}
+	payload.Attributes["energy"] = kWh
+	return payload
+}
@@ -0,0 +1,124 @@
+package sensor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+// writeRAPLDomains writes the energy counters of RAPL domains to root.
+func writeRAPLDomains(t *testing.T, root string, domains map[string]map[string]string) {
+	t.Helper()
+	for dir, content := range domains {
+		require.NoError(t, os.MkdirAll(filepath.Join(root, dir), 0o755))
+		for name, value := range content {
+			require.NoError(t, os.WriteFile(filepath.Join(root, dir, name), []byte(value+"\n"), 0o600))
+		}
+	}
+}
+
+func TestPackagePower(t *testing.T) {
+	const maxRange = "262143328850"
+	root := t.TempDir()
+	domains := func(pkg, core, dram string) {
+		writeRAPLDomains(t, root, map[string]map[string]string{
+			"intel-rapl:0":   {"name": "package-0", "energy_uj": pkg, "max_energy_range_uj": maxRange},
+			"intel-rapl:0:0": {"name": "core", "energy_uj": core, "max_energy_range_uj": maxRange},
+			"intel-rapl:0:2": {"name": "dram", "energy_uj": dram, "max_energy_range_uj": maxRange},
+		})
+	}
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	reader := newRAPLReader(root)
+	reader.now = func() time.Time { return now }
+	power := &PackagePower{reader: reader}
+	energy := &PackagePower{reader: reader, energy: true}
+
+	// The power draw is unknown after the first reading, the consumed energy starts at zero.
+	domains("1000000", "500000", "100000")
+	p, err := power.Run(context.Background())
+	require.NoError(t, err)
+	require.Nil(t, p)
+	p, err = energy.Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 0.0, p.State)
+
+	now = now.Add(2 * time.Second)
+	domains("16000000", "10500000", "2100000")
+	p, err = power.Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 7.5, p.State)
+	require.EqualValues(t, map[string]interface{}{
+		"package": 7.5,
+		"core":    5.0,
+		"dram":    1.0,
+		"energy":  0.000004,
+	}, p.Attributes)
+
+	// Both sensors share the reading of a single update.
+	domains("3600000000", "10500000", "2100000")
+	p, err = energy.Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 0.000004, p.State)
+	require.Empty(t, p.Attributes)
+
+	now = now.Add(raplReadingMaxAge)
+	p, err = energy.Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 0.001, p.State)
+}
+
+func TestPackagePowerWrapAround(t *testing.T) {
+	const maxRange = 262143328850
+	root := t.TempDir()
+	packages := func(pkg0, pkg1 uint64) {
+		writeRAPLDomains(t, root, map[string]map[string]string{
+			"intel-rapl:0": {"name": "package-0", "energy_uj": strconv.FormatUint(pkg0, 10), "max_energy_range_uj": strconv.FormatUint(maxRange, 10)},
+			"intel-rapl:1": {"name": "package-1", "energy_uj": strconv.FormatUint(pkg1, 10), "max_energy_range_uj": strconv.FormatUint(maxRange, 10)},
+		})
+	}
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	reader := newRAPLReader(root)
+	reader.now = func() time.Time { return now }
+	energy := &PackagePower{reader: reader, energy: true}
+
+	// The initial value of the counters is not counted as consumption.
+	packages(maxRange-10_000_000, 100_000_000_000)
+	p, err := energy.Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 0.0, p.State)
+
+	// The counter of package-0 wraps around after reaching max_energy_range_uj.
+	now = now.Add(10 * time.Second)
+	packages(350_000_000, 100_360_000_000)
+	reading, err := reader.read()
+	require.NoError(t, err)
+	require.Equal(t, map[string]float64{"package": 72}, reading.power)
+	require.Equal(t, uint64(720_000_000), reading.energy)
+
+	p, err = energy.Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 0.0002, p.State)
+}
+
+func TestPackagePowerDomains(t *testing.T) {
+	root := t.TempDir()
+	writeRAPLDomains(t, root, map[string]map[string]string{
+		"intel-rapl:0":   {"name": "package-0", "energy_uj": "1000000", "max_energy_range_uj": "262143328850"},
+		"intel-rapl:0:1": {"name": "uncore", "energy_uj": "1000", "max_energy_range_uj": "262143328850"},
+		// The MMIO interface duplicates the package domain.
+		"intel-rapl-mmio:0": {"name": "package-0", "energy_uj": "1000000", "max_energy_range_uj": "262143328850"},
+	})
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "intel-rapl"), 0o755))
+
+	domains, err := newRAPLReader(root).readDomains()
+	require.NoError(t, err)
+	require.Len(t, domains, 2)
+
+	_, err = (&PackagePower{reader: newRAPLReader(t.TempDir())}).Run(context.Background())
+	require.Error(t, err)
+}
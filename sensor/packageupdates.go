@@ -0,0 +1,315 @@
+package sensor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os/exec"
+	"slices"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"hacompanion/entity"
+)
+
+// maxReportedPackages limits the package list attribute, as Home Assistant
+// does not record attributes that exceed a certain size.
+const maxReportedPackages = 100
+
+// pendingUpdates contains the pending updates of a single package manager.
+type pendingUpdates struct {
+	packages []string
+	security int
+}
+
+// packageManager queries a package manager for pending updates without
+// refreshing its package lists.
+type packageManager struct {
+	name   string
+	binary string
+	check  func(ctx context.Context) (pendingUpdates, error)
+}
+
+var packageManagers = []packageManager{
+	{name: "apt", binary: "apt", check: checkAptUpdates},
+	{name: "dnf", binary: "dnf", check: checkDnfUpdates},
+	{name: "pacman", binary: "checkupdates", check: checkPacmanUpdates},
+	{name: "flatpak", binary: "flatpak", check: checkFlatpakUpdates},
+}
+
+var errNoPackageManager = errors.New("no supported package manager could be queried for updates")
+
+// PackageUpdates reports the number of pending package updates.
+// Since querying package managers is slow, the result is cached and
+// refreshed in the background in the configured interval.
+type PackageUpdates struct {
+	interval time.Duration
+	managers []string
+	now      func() time.Time
+	// checker queries the package managers, it is replaced in tests.
+	checker func(ctx context.Context) (*entity.Payload, error)
+
+	// checkMu ensures that only one check runs at a time.
+	checkMu sync.Mutex
+	mu      sync.Mutex
+	payload *entity.Payload
+	err     error
+	checked time.Time
+	// reportedMissing is set once the missing package manager was logged.
+	reportedMissing bool
+}
+
+func NewPackageUpdates(m entity.Meta) *PackageUpdates {
+	p := &PackageUpdates{
+		interval: time.Hour,
+		managers: m.GetStringSlice("managers"),
+		now:      time.Now,
+	}
+	p.checker = p.check
+	if interval, err := time.ParseDuration(m.GetString("interval")); err == nil && interval > 0 {
+		p.interval = interval
+	}
+	return p
+}
+
+// Run returns the cached result. Only the first check runs synchronously,
+// so the sensor is registered with a state. Later checks are run by Watch,
+// so a slow package manager does not delay the updates of other sensors.
+func (pu *PackageUpdates) Run(ctx context.Context) (*entity.Payload, error) {
+	pu.mu.Lock()
+	checked := !pu.checked.IsZero()
+	pu.mu.Unlock()
+	if !checked {
+		pu.refresh(ctx)
+	}
+	pu.mu.Lock()
+	defer pu.mu.Unlock()
+	return pu.payload, pu.err
+}
+
+// Watch refreshes the result in the configured interval and
+// pushes it once a check is complete.
+func (pu *PackageUpdates) Watch(ctx context.Context, notify func()) error {
+	t := time.NewTicker(pu.interval)
+	defer t.Stop()
+	for {
+		select {
+		case <-t.C:
+		case <-ctx.Done():
+			return nil
+		}
+		if pu.refresh(ctx) {
+			notify()
+		}
+	}
+}
+
+// refresh checks for updates and caches the result. It returns false
+// without waiting if another check is already running.
+func (pu *PackageUpdates) refresh(ctx context.Context) bool {
+	if !pu.checkMu.TryLock() {
+		return false
+	}
+	defer pu.checkMu.Unlock()
+
+	payload, err := pu.checker(ctx)
+	if ctx.Err() != nil {
+		return false
+	}
+	if errors.Is(err, errNoPackageManager) {
+		// This does not change until the companion is restarted, so it is
+		// only logged once and the sensor is reported as unavailable.
+		if !pu.reportedMissing {
+			log.Printf("package updates sensor: %s", err)
+			pu.reportedMissing = true
+		}
+		payload, err = entity.NewPayload(), nil
+		payload.State = "unavailable"
+	}
+	pu.mu.Lock()
+	pu.payload, pu.err = payload, err
+	pu.checked = pu.now()
+	pu.mu.Unlock()
+	return true
+}
+
+func (pu *PackageUpdates) check(ctx context.Context) (*entity.Payload, error) {
+	packages := make([]string, 0)
+	var security int
+	counts := make(map[string]int)
+	for _, manager := range packageManagers {
+		if len(pu.managers) > 0 && !slices.Contains(pu.managers, manager.name) {
+			continue
+		}
+		if _, err := exec.LookPath(manager.binary); err != nil {
+			continue
+		}
+		updates, err := manager.check(ctx)
+		if err != nil {
+			log.Printf("failed to check %s for updates: %s", manager.name, err)
+			continue
+		}
+		counts[manager.name] = len(updates.packages)
+		packages = append(packages, updates.packages...)
+		security += updates.security
+	}
+	if len(counts) == 0 {
+		return nil, errNoPackageManager
+	}
+	sort.Strings(packages)
+
+	p := entity.NewPayload()
+	p.State = len(packages)
+	if len(packages) > maxReportedPackages {
+		packages = packages[:maxReportedPackages]
+	}
+	p.Attributes["packages"] = packages
+	p.Attributes["security_updates"] = security
+	p.Attributes["package_managers"] = counts
+	p.Attributes["last_checked"] = pu.now().Format(time.RFC3339)
+	if len(packages) > 0 {
+		p.Icon = "mdi:package-up"
+	}
+	return p, nil
+}
+
+// runPackageManager runs a package manager command and returns its output.
+// Exit codes listed in okCodes are used to signal if updates are available and are not treated as errors.
+func runPackageManager(ctx context.Context, okCodes []int, name string, args ...string) (string, error) {
+	var out bytes.Buffer
+	cmd := exec.CommandContext(ctx, name, args...)
+	cmd.Stdout = &out
+	err := cmd.Run()
+	var exitErr *exec.ExitError
+	if errors.As(err, &exitErr) && slices.Contains(okCodes, exitErr.ExitCode()) {
+		err = nil
+	}
+	if err != nil {
+		return "", fmt.Errorf("failed to run %s: %w", name, err)
+	}
+	return out.String(), nil
+}
+
+func checkAptUpdates(ctx context.Context) (pendingUpdates, error) {
+	// apt list only reads the cached package lists.
+	out, err := runPackageManager(ctx, nil, "apt", "list", "--upgradable")
+	if err != nil {
+		return pendingUpdates{}, err
+	}
+	return processAptUpdates(out), nil
+}
+
+// processAptUpdates parses the output of `apt list --upgradable`.
+func processAptUpdates(output string) pendingUpdates {
+	var updates pendingUpdates
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		// Lines look like "name/suite1,suite2 version arch [upgradable from: version]".
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 || !strings.Contains(fields[0], "/") {
+			continue
+		}
+		name, suites, _ := strings.Cut(fields[0], "/")
+		updates.packages = append(updates.packages, name)
+		if strings.Contains(suites, "-security") {
+			updates.security++
+		}
+	}
+	return updates
+}
+
+func checkDnfUpdates(ctx context.Context) (pendingUpdates, error) {
+	// -C only uses the metadata cache, check-update exits with 100 if updates are available.
+	out, err := runPackageManager(ctx, []int{100}, "dnf", "-q", "-C", "check-update")
+	if err != nil {
+		return pendingUpdates{}, err
+	}
+	updates := processDnfUpdates(out)
+	if advisories, err := runPackageManager(ctx, nil, "dnf", "-q", "-C", "updateinfo", "list", "--security"); err == nil {
+		updates.security = processDnfSecurityAdvisories(advisories)
+	}
+	return updates, nil
+}
+
+// processDnfUpdates parses the output of `dnf check-update`.
+func processDnfUpdates(output string) pendingUpdates {
+	var updates pendingUpdates
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		// Lines look like "name.arch version repository".
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 3 {
+			// Obsoleting packages are listed after an additional header.
+			if strings.HasPrefix(strings.TrimSpace(scanner.Text()), "Obsoleting") {
+				break
+			}
+			continue
+		}
+		name := fields[0]
+		if i := strings.LastIndex(name, "."); i > 0 {
+			name = name[:i]
+		}
+		updates.packages = append(updates.packages, name)
+	}
+	return updates
+}
+
+// processDnfSecurityAdvisories counts the packages with pending security advisories.
+func processDnfSecurityAdvisories(output string) int {
+	packages := make(map[string]bool)
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 3 {
+			packages[fields[len(fields)-1]] = true
+		}
+	}
+	return len(packages)
+}
+
+func checkPacmanUpdates(ctx context.Context) (pendingUpdates, error) {
+	// checkupdates uses a copy of the sync database and exits with 2 if there are no updates.
+	out, err := runPackageManager(ctx, []int{2}, "checkupdates")
+	if err != nil {
+		return pendingUpdates{}, err
+	}
+	return processPacmanUpdates(out), nil
+}
+
+// processPacmanUpdates parses the output of `checkupdates`.
+func processPacmanUpdates(output string) pendingUpdates {
+	var updates pendingUpdates
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		// Lines look like "name old-version -> new-version".
+		if fields := strings.Fields(scanner.Text()); len(fields) > 0 {
+			updates.packages = append(updates.packages, fields[0])
+		}
+	}
+	return updates
+}
+
+func checkFlatpakUpdates(ctx context.Context) (pendingUpdates, error) {
+	out, err := runPackageManager(ctx, nil, "flatpak", "remote-ls", "--updates", "--cached", "--columns=application")
+	if err != nil {
+		return pendingUpdates{}, err
+	}
+	return processFlatpakUpdates(out), nil
+}
+
+// processFlatpakUpdates parses the output of `flatpak remote-ls --updates`.
+func processFlatpakUpdates(output string) pendingUpdates {
+	var updates pendingUpdates
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		if app := strings.TrimSpace(scanner.Text()); app != "" {
+			updates.packages = append(updates.packages, app)
+		}
+	}
+	return updates
+}
@@ -0,0 +1,128 @@
+package sensor
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"hacompanion/entity"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPackageUpdates_Apt(t *testing.T) {
+	input := `Listing...
+firefox/jammy-updates,jammy-security 128.0+build2-0ubuntu0.22.04.1 amd64 [upgradable from: 127.0.2+build1-0ubuntu0.22.04.1]
+libssl3/jammy-security 3.0.2-0ubuntu1.16 amd64 [upgradable from: 3.0.2-0ubuntu1.15]
+vim/jammy-updates 2:8.2.3995-1ubuntu2.17 amd64 [upgradable from: 2:8.2.3995-1ubuntu2.16]
+`
+	updates := processAptUpdates(input)
+	require.Equal(t, []string{"firefox", "libssl3", "vim"}, updates.packages)
+	require.Equal(t, 2, updates.security)
+}
+
+func TestPackageUpdates_Dnf(t *testing.T) {
+	input := `
+kernel.x86_64                 6.9.7-200.fc40        updates
+openssl-libs.x86_64           1:3.2.2-3.fc40        updates
+python3-requests.noarch       2.31.0-6.fc40         updates
+Obsoleting Packages
+grub2-tools.x86_64            1:2.06-121.fc40       updates
+    grub2-tools.x86_64        1:2.06-118.fc40       @updates
+`
+	advisories := `
+FEDORA-2024-1a2b3c4d5e important/Sec. openssl-libs-1:3.2.2-3.fc40.x86_64
+FEDORA-2024-6f7e8d9c0b moderate/Sec.  kernel-6.9.7-200.fc40.x86_64
+FEDORA-2024-0b1c2d3e4f moderate/Sec.  kernel-6.9.7-200.fc40.x86_64
+`
+	updates := processDnfUpdates(input)
+	require.Equal(t, []string{"kernel", "openssl-libs", "python3-requests"}, updates.packages)
+	require.Equal(t, 2, processDnfSecurityAdvisories(advisories))
+}
+
+func TestPackageUpdates_Pacman(t *testing.T) {
+	cases := []struct {
+		name     string
+		script   string
+		packages []string
+		err      bool
+	}{
+		{"updates", "printf 'linux 6.9.6.arch1-1 -> 6.9.7.arch1-1\\nfirefox 127.0-1 -> 127.0.2-1\\n'", []string{"linux", "firefox"}, false},
+		// checkupdates exits with 2 if there are no updates.
+		{"no updates", "exit 2", nil, false},
+		{"failure", "exit 1", nil, true},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			out, err := runPackageManager(context.Background(), []int{2}, "sh", "-c", tc.script)
+			if tc.err {
+				require.Error(t, err)
+				return
+			}
+			require.NoError(t, err)
+			require.Equal(t, tc.packages, processPacmanUpdates(out).packages)
+		})
+	}
+}
+
+func TestPackageUpdates_Flatpak(t *testing.T) {
+	cases := []struct {
+		name     string
+		input    string
+		packages []string
+	}{
+		{"updates", "org.mozilla.firefox\norg.freedesktop.Platform.GL.default\n", []string{"org.mozilla.firefox", "org.freedesktop.Platform.GL.default"}},
+		{"blank lines", "\norg.gnome.Calculator\n  \n", []string{"org.gnome.Calculator"}},
+		{"no updates", "", nil},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.packages, processFlatpakUpdates(tc.input).packages)
+		})
+	}
+}
+
+func TestPackageUpdates_Refresh(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	pu := NewPackageUpdates(entity.Meta{"interval": "1h"})
+	pu.now = func() time.Time { return now }
+	var checks int
+	pu.checker = func(ctx context.Context) (*entity.Payload, error) {
+		checks++
+		p := entity.NewPayload()
+		p.State = checks
+		return p, nil
+	}
+
+	// The first run checks synchronously, later runs use the cached result.
+	p, err := pu.Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, p.State)
+	now = now.Add(2 * time.Hour)
+	p, err = pu.Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 1, p.State)
+
+	// The result is only refreshed in the background.
+	require.True(t, pu.refresh(context.Background()))
+	p, err = pu.Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 2, p.State)
+
+	// A refresh does not wait for a running check.
+	pu.checkMu.Lock()
+	require.False(t, pu.refresh(context.Background()))
+	pu.checkMu.Unlock()
+	require.Equal(t, 2, checks)
+}
+
+func TestPackageUpdates_NoManager(t *testing.T) {
+	pu := NewPackageUpdates(entity.Meta{})
+	pu.checker = func(ctx context.Context) (*entity.Payload, error) {
+		return nil, errNoPackageManager
+	}
+	p, err := pu.Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "unavailable", p.State)
+	require.True(t, pu.reportedMissing)
+}
@@ -3,7 +3,7 @@ package sensor
 import (
 	"context"
 	"fmt"
-	"io/fs"
+	"math"
 	"os"
 	"path/filepath"
 	"strconv"
@@ -13,79 +13,272 @@ import (
 	"hacompanion/util"
 )
 
+// Power reports the charge and telemetry of one or more batteries.
 type Power struct {
-	Battery string
+	root      string
+	Battery   string
+	Batteries []string
+	Aggregate bool
 }
 
 func NewPower(m entity.Meta) *Power {
-	c := &Power{Battery: "BAT0"}
+	c := &Power{
+		root:      "/sys/class/power_supply",
+		Battery:   "BAT0",
+		Batteries: m.GetStringSlice("batteries"),
+		Aggregate: m.GetBool("aggregate"),
+	}
 	if b := m.GetString("battery"); b != "" {
 		c.Battery = b
 	}
 	return c
 }
 
+// legacyBatteryAttributes are reported with the raw sysfs values in µV and µAh,
+// as they were before the converted values were added.
+var legacyBatteryAttributes = []string{"voltage_now", "voltage_min_design", "charge_now", "charge_full"}
+
+// battery contains the values of a single power supply. sysfs reports
+// values in micro units, they are converted to V, A, W, Ah and Wh.
+type battery struct {
+	name             string
+	raw              map[string]string
+	present          string
+	status           string
+	level            string
+	technology       string
+	capacity         float64
+	hasCapacity      bool
+	cycleCount       int
+	voltageNow       float64
+	voltageMinDesign float64
+	currentNow       float64
+	powerNow         float64
+	chargeNow        float64
+	chargeFull       float64
+	chargeFullDesign float64
+	energyNow        float64
+	energyFull       float64
+	energyFullDesign float64
+}
+
+// power returns the power draw in W, calculated from the current if the power is not reported.
+func (b battery) power() float64 {
+	if b.powerNow != 0 {
+		return math.Abs(b.powerNow)
+	}
+	return math.Abs(b.currentNow * b.voltageNow)
+}
+
+// voltage returns the voltage used to convert charge to energy.
+func (b battery) voltage() float64 {
+	if b.voltageMinDesign > 0 {
+		return b.voltageMinDesign
+	}
+	return b.voltageNow
+}
+
+// energy returns the current, full and design energy in Wh.
+// Batteries that only report their charge are converted using their voltage.
+func (b battery) energy() (now, full, design float64) {
+	if b.energyFull > 0 {
+		return b.energyNow, b.energyFull, b.energyFullDesign
+	}
+	v := b.voltage()
+	return b.chargeNow * v, b.chargeFull * v, b.chargeFullDesign * v
+}
+
 func (pwr Power) Run(ctx context.Context) (*entity.Payload, error) {
-	dir := fmt.Sprintf("/sys/class/power_supply/%s", pwr.Battery)
-	_, err := os.Stat(dir)
-	if os.IsNotExist(err) {
-		return nil, fmt.Errorf("failed to read battery status from %s: %w", dir, err)
+	names := []string{pwr.Battery}
+	if pwr.Aggregate {
+		names = pwr.Batteries
+		if len(names) == 0 {
+			names = pwr.systemBatteries()
+		}
+		if len(names) == 0 {
+			return nil, fmt.Errorf("failed to find any batteries in %s", pwr.root)
+		}
 	}
-	realPath, err := filepath.EvalSymlinks(dir)
-	if err != nil {
-		return nil, fmt.Errorf("failed to eval symlink %s: %w", dir, err)
+	var batteries []battery
+	for _, name := range names {
+		dir := filepath.Join(pwr.root, name)
+		if _, err := os.Stat(dir); os.IsNotExist(err) {
+			return nil, fmt.Errorf("failed to read battery status from %s: %w", dir, err)
+		}
+		batteries = append(batteries, pwr.readBattery(dir))
 	}
+	return pwr.process(batteries, pwr.acOnline()), nil
+}
+
+func (pwr Power) process(batteries []battery, acOnline string) *entity.Payload {
 	p := entity.NewPayload()
-	err = filepath.WalkDir(realPath, func(path string, d fs.DirEntry, err error) error {
-		if err != nil {
-			return err
-		}
-		if d.IsDir() {
-			return nil
-		}
-		switch d.Name() {
-		case "capacity":
-			p.State = pwr.optimisticRead(filepath.Join(realPath, d.Name()))
-		case "capacity_level":
-			p.Attributes["level"] = pwr.optimisticRead(filepath.Join(realPath, d.Name()))
-		case "present":
-			p.Attributes["battery_present"] = util.StringToOnOff(pwr.optimisticRead(filepath.Join(realPath, d.Name())))
-		case "status":
-			p.Attributes["status"] = pwr.optimisticRead(filepath.Join(realPath, d.Name()))
-		case "voltage_now":
-			p.Attributes["voltage_now"] = pwr.optimisticRead(filepath.Join(realPath, d.Name()))
-		case "voltage_min_design":
-			p.Attributes["voltage_min_design"] = pwr.optimisticRead(filepath.Join(realPath, d.Name()))
-		case "charge_now":
-			p.Attributes["charge_now"] = pwr.optimisticRead(filepath.Join(realPath, d.Name()))
-		case "charge_full":
-			p.Attributes["charge_full"] = pwr.optimisticRead(filepath.Join(realPath, d.Name()))
+	var energyNow, energyFull, energyFullDesign, power float64
+	var status string
+	for _, b := range batteries {
+		now, full, design := b.energy()
+		energyNow += now
+		energyFull += full
+		energyFullDesign += design
+		power += b.power()
+		// A single charging battery means the system is charging.
+		if status == "" || b.status == "Charging" || (b.status == "Discharging" && status != "Charging") {
+			status = b.status
 		}
-		return nil
-	})
-	// Check if a power cable is attached.
-	acLink := "/sys/class/power_supply/AC"
-	if exists, _ := util.FileExists(acLink); exists {
-		if realPath, fileErr := filepath.EvalSymlinks(acLink); fileErr == nil {
-			acInfo := filepath.Join(realPath, "online")
-			if exists, _ := util.FileExists(acLink); exists {
-				p.Attributes["ac_connected"] = util.StringToOnOff(pwr.optimisticRead(acInfo))
+	}
+
+	if len(batteries) == 1 {
+		b := batteries[0]
+		if b.hasCapacity {
+			p.State = int(b.capacity)
+		}
+		pwr.setAttribute(p, "level", b.level)
+		pwr.setAttribute(p, "battery_present", util.StringToOnOff(b.present))
+		pwr.setAttribute(p, "technology", b.technology)
+		for _, name := range legacyBatteryAttributes {
+			pwr.setAttribute(p, name, b.raw[name])
+		}
+		pwr.setNumber(p, "voltage_now_v", b.voltageNow)
+		pwr.setNumber(p, "voltage_min_design_v", b.voltageMinDesign)
+		pwr.setNumber(p, "charge_now_ah", b.chargeNow)
+		pwr.setNumber(p, "charge_full_ah", b.chargeFull)
+		pwr.setNumber(p, "charge_full_design_ah", b.chargeFullDesign)
+		if b.cycleCount > 0 {
+			p.Attributes["cycle_count"] = b.cycleCount
+		}
+	} else {
+		names := make([]string, 0, len(batteries))
+		for _, b := range batteries {
+			names = append(names, b.name)
+			if b.hasCapacity {
+				p.Attributes[strings.ToLower(b.name)+"_capacity"] = int(b.capacity)
 			}
 		}
+		p.Attributes["batteries"] = names
+		if energyFull > 0 {
+			p.State = int(math.Round(energyNow / energyFull * 100))
+		}
 	}
 
-	var charging bool
-	if s, ok := p.Attributes["status"].(string); ok {
-		charging = strings.TrimSpace(s) == "Charging"
+	pwr.setAttribute(p, "status", status)
+	pwr.setNumber(p, "energy_now_wh", energyNow)
+	pwr.setNumber(p, "energy_full_wh", energyFull)
+	pwr.setNumber(p, "energy_full_design_wh", energyFullDesign)
+	p.Attributes["power_draw"] = util.RoundToTwoDecimals(power)
+	if energyFullDesign > 0 {
+		p.Attributes["health"] = util.RoundToTwoDecimals(energyFull / energyFullDesign * 100)
 	}
-
-	if ac, ok := p.Attributes["ac_connected"].(string); ok && ac == "on" {
-		charging = true
+	// The remaining time is reported in minutes.
+	if power > 0 {
+		switch status {
+		case "Discharging":
+			p.Attributes["time_to_empty"] = int(energyNow / power * 60)
+		case "Charging":
+			p.Attributes["time_to_full"] = int(math.Max(energyFull-energyNow, 0) / power * 60)
+		}
 	}
+	pwr.setAttribute(p, "ac_connected", acOnline)
 
+	charging := status == "Charging" || acOnline == "on"
 	p.Icon = pwr.resolveIcon(p.State, charging)
+	return p
+}
 
-	return p, err
+func (pwr Power) setAttribute(p *entity.Payload, name, value string) {
+	if value != "" {
+		p.Attributes[name] = value
+	}
+}
+
+func (pwr Power) setNumber(p *entity.Payload, name string, value float64) {
+	if value != 0 {
+		p.Attributes[name] = util.RoundToTwoDecimals(value)
+	}
+}
+
+// readBattery reads all known values of a power supply directory.
+func (pwr Power) readBattery(dir string) battery {
+	read := func(name string) string {
+		return pwr.optimisticRead(filepath.Join(dir, name))
+	}
+	// Values in micro units are converted to their base unit.
+	micro := func(name string) float64 {
+		value, err := strconv.ParseFloat(read(name), 64)
+		if err != nil {
+			return 0
+		}
+		return value / 1e6
+	}
+	b := battery{
+		name:             filepath.Base(dir),
+		present:          read("present"),
+		status:           read("status"),
+		level:            read("capacity_level"),
+		technology:       read("technology"),
+		voltageNow:       micro("voltage_now"),
+		voltageMinDesign: micro("voltage_min_design"),
+		currentNow:       micro("current_now"),
+		powerNow:         micro("power_now"),
+		chargeNow:        micro("charge_now"),
+		chargeFull:       micro("charge_full"),
+		chargeFullDesign: micro("charge_full_design"),
+		energyNow:        micro("energy_now"),
+		energyFull:       micro("energy_full"),
+		energyFullDesign: micro("energy_full_design"),
+		raw:              make(map[string]string, len(legacyBatteryAttributes)),
+	}
+	for _, name := range legacyBatteryAttributes {
+		b.raw[name] = read(name)
+	}
+	if capacity, err := strconv.ParseFloat(read("capacity"), 64); err == nil {
+		b.capacity = capacity
+		b.hasCapacity = true
+	}
+	b.cycleCount, _ = strconv.Atoi(read("cycle_count"))
+	return b
+}
+
+// systemBatteries returns the names of all batteries that power the system.
+// Batteries of peripherals like mice or headsets have the "Device" scope.
+func (pwr Power) systemBatteries() []string {
+	entries, err := os.ReadDir(pwr.root)
+	if err != nil {
+		return nil
+	}
+	var names []string
+	for _, entry := range entries {
+		dir := filepath.Join(pwr.root, entry.Name())
+		if pwr.optimisticRead(filepath.Join(dir, "type")) != "Battery" {
+			continue
+		}
+		if pwr.optimisticRead(filepath.Join(dir, "scope")) == "Device" {
+			continue
+		}
+		names = append(names, entry.Name())
+	}
+	return names
+}
+
+// acOnline checks if a power cable is attached to any of the mains power supplies.
+func (pwr Power) acOnline() string {
+	entries, err := os.ReadDir(pwr.root)
+	if err != nil {
+		return ""
+	}
+	var online string
+	for _, entry := range entries {
+		dir := filepath.Join(pwr.root, entry.Name())
+		if pwr.optimisticRead(filepath.Join(dir, "type")) != "Mains" {
+			continue
+		}
+		state := util.StringToOnOff(pwr.optimisticRead(filepath.Join(dir, "online")))
+		if state == "on" {
+			return state
+		}
+		if state != "" {
+			online = state
+		}
+	}
+	return online
 }
 
 func (pwr Power) optimisticRead(file string) string {
@@ -94,12 +287,12 @@ func (pwr Power) optimisticRead(file string) string {
 		return ""
 	}
 
-	return string(b)
+	return strings.TrimSpace(string(b))
 }
 
 func (pwr Power) resolveIcon(state any, charging bool) string {
-	num, err := strconv.Atoi(strings.TrimSpace(state.(string)))
-	if err != nil {
+	num, ok := state.(int)
+	if !ok {
 		return "mdi:battery-unknown"
 	}
 
@@ -0,0 +1,138 @@
+package sensor
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"hacompanion/entity"
+
+	"github.com/stretchr/testify/require"
+)
+
+func writePowerSupply(t *testing.T, root, name string, files map[string]string) {
+	t.Helper()
+	dir := filepath.Join(root, name)
+	require.NoError(t, os.MkdirAll(dir, 0o755))
+	for file, content := range files {
+		require.NoError(t, os.WriteFile(filepath.Join(dir, file), []byte(content+"\n"), 0o600))
+	}
+}
+
+func newTestPowerSupplies(t *testing.T) string {
+	t.Helper()
+	root := t.TempDir()
+	writePowerSupply(t, root, "BAT0", map[string]string{
+		"type":               "Battery",
+		"present":            "1",
+		"status":             "Discharging",
+		"capacity":           "50",
+		"capacity_level":     "Normal",
+		"technology":         "Li-poly",
+		"cycle_count":        "321",
+		"voltage_now":        "11800000",
+		"voltage_min_design": "11400000",
+		"power_now":          "10000000",
+		"energy_now":         "25000000",
+		"energy_full":        "50000000",
+		"energy_full_design": "57000000",
+	})
+	writePowerSupply(t, root, "BAT1", map[string]string{
+		"type":               "Battery",
+		"present":            "1",
+		"status":             "Unknown",
+		"capacity":           "100",
+		"voltage_now":        "10000000",
+		"voltage_min_design": "10000000",
+		"current_now":        "0",
+		"charge_now":         "2000000",
+		"charge_full":        "2000000",
+		"charge_full_design": "2500000",
+	})
+	writePowerSupply(t, root, "hidpp_battery_0", map[string]string{
+		"type":     "Battery",
+		"scope":    "Device",
+		"capacity": "80",
+	})
+	writePowerSupply(t, root, "ADP1", map[string]string{
+		"type":   "Mains",
+		"online": "0",
+	})
+	return root
+}
+
+func TestPower(t *testing.T) {
+	output := &entity.Payload{
+		State: 50,
+		Icon:  "mdi:battery-50",
+		Attributes: map[string]interface{}{
+			"level":                 "Normal",
+			"battery_present":       "on",
+			"status":                "Discharging",
+			"technology":            "Li-poly",
+			"cycle_count":           321,
+			"voltage_now":           "11800000",
+			"voltage_min_design":    "11400000",
+			"voltage_now_v":         11.8,
+			"voltage_min_design_v":  11.4,
+			"energy_now_wh":         float64(25),
+			"energy_full_wh":        float64(50),
+			"energy_full_design_wh": float64(57),
+			"power_draw":            float64(10),
+			"health":                87.71,
+			"time_to_empty":         150,
+			"ac_connected":          "off",
+		},
+	}
+
+	pwr := NewPower(entity.Meta{})
+	pwr.root = newTestPowerSupplies(t)
+
+	res, err := pwr.Run(context.Background())
+	require.NoError(t, err)
+	require.EqualValues(t, output, res)
+}
+
+func TestPower_Aggregate(t *testing.T) {
+	output := &entity.Payload{
+		State: 64,
+		Icon:  "mdi:battery-60",
+		Attributes: map[string]interface{}{
+			"batteries":             []string{"BAT0", "BAT1"},
+			"bat0_capacity":         50,
+			"bat1_capacity":         100,
+			"status":                "Discharging",
+			"energy_now_wh":         float64(45),
+			"energy_full_wh":        float64(70),
+			"energy_full_design_wh": float64(82),
+			"power_draw":            float64(10),
+			"health":                85.36,
+			"time_to_empty":         270,
+			"ac_connected":          "off",
+		},
+	}
+
+	pwr := NewPower(entity.Meta{"aggregate": true})
+	pwr.root = newTestPowerSupplies(t)
+
+	res, err := pwr.Run(context.Background())
+	require.NoError(t, err)
+	require.EqualValues(t, output, res)
+}
+
+func TestPower_Charge(t *testing.T) {
+	pwr := NewPower(entity.Meta{"battery": "BAT1"})
+	pwr.root = newTestPowerSupplies(t)
+
+	res, err := pwr.Run(context.Background())
+	require.NoError(t, err)
+	// The raw values are kept for compatibility, the converted values use a unit suffix.
+	require.Equal(t, "2000000", res.Attributes["charge_now"])
+	require.Equal(t, "2000000", res.Attributes["charge_full"])
+	require.Equal(t, "10000000", res.Attributes["voltage_now"])
+	require.Equal(t, float64(2), res.Attributes["charge_now_ah"])
+	require.Equal(t, float64(2), res.Attributes["charge_full_ah"])
+	require.Equal(t, 2.5, res.Attributes["charge_full_design_ah"])
+	require.Equal(t, float64(20), res.Attributes["energy_now_wh"])
+}
@@ -0,0 +1,90 @@
+package sensor
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"log"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+
+	"hacompanion/entity"
+	"hacompanion/util"
+)
+
+// Pressure reports the Pressure Stall Information of the CPU, memory and IO.
+type Pressure struct {
+	root       string
+	resources  []string
+	logMissing sync.Once
+}
+
+func NewPressure() *Pressure {
+	return &Pressure{
+		root:      "/proc/pressure",
+		resources: []string{"cpu", "memory", "io"},
+	}
+}
+
+func (pr *Pressure) Run(ctx context.Context) (*entity.Payload, error) {
+	outputs := make(map[string]string, len(pr.resources))
+	for _, resource := range pr.resources {
+		b, err := os.ReadFile(filepath.Join(pr.root, resource))
+		if err != nil {
+			// Kernels without PSI support don't have /proc/pressure, kernels
+			// booted with psi=0 return EOPNOTSUPP. Both won't change at runtime,
+			// so this is only logged once instead of failing on every run.
+			if errors.Is(err, fs.ErrNotExist) || errors.Is(err, syscall.EOPNOTSUPP) {
+				pr.logMissing.Do(func() {
+					log.Printf("pressure stall information is not supported by this kernel: %s", err)
+				})
+				p := entity.NewPayload()
+				p.State = "unavailable"
+				return p, nil
+			}
+			return nil, err
+		}
+		outputs[resource] = string(b)
+	}
+	return pr.process(outputs)
+}
+
+func (pr *Pressure) process(outputs map[string]string) (*entity.Payload, error) {
+	p := entity.NewPayload()
+	for resource, output := range outputs {
+		scanner := bufio.NewScanner(strings.NewReader(output))
+		for scanner.Scan() {
+			// Lines look like "some avg10=0.00 avg60=0.00 avg300=0.00 total=0".
+			fields := strings.Fields(scanner.Text())
+			if len(fields) == 0 {
+				continue
+			}
+			kind := fields[0]
+			for _, field := range fields[1:] {
+				name, value, ok := strings.Cut(field, "=")
+				if !ok || !strings.HasPrefix(name, "avg") {
+					continue
+				}
+				float, err := strconv.ParseFloat(value, 64)
+				if err != nil {
+					return nil, fmt.Errorf("failed to parse %s pressure %s: %w", resource, field, err)
+				}
+				float = util.RoundToTwoDecimals(float)
+				p.Attributes[fmt.Sprintf("%s_%s_%s", resource, kind, name)] = float
+				if resource == "memory" && kind == "some" && name == "avg10" {
+					p.State = float
+				}
+			}
+		}
+	}
+	if p.State == nil {
+		return nil, fmt.Errorf("could not determine memory pressure state: %v", outputs)
+	}
+	return p, nil
+}
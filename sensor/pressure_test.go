@@ -0,0 +1,62 @@
+package sensor
+
+import (
+	"context"
+	"testing"
+
+	"hacompanion/entity"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestPressure(t *testing.T) {
+	inputs := map[string]string{
+		"cpu": `some avg10=1.52 avg60=0.98 avg300=0.45 total=123456789
+full avg10=0.00 avg60=0.00 avg300=0.00 total=0
+`,
+		"memory": `some avg10=12.34 avg60=5.67 avg300=1.23 total=987654
+full avg10=8.10 avg60=3.20 avg300=0.80 total=654321
+`,
+		"io": `some avg10=0.25 avg60=0.10 avg300=0.05 total=4242
+full avg10=0.20 avg60=0.08 avg300=0.04 total=4040
+`,
+	}
+	output := &entity.Payload{
+		State: 12.34,
+		Attributes: map[string]interface{}{
+			"cpu_some_avg10":     1.52,
+			"cpu_some_avg60":     0.98,
+			"cpu_some_avg300":    0.45,
+			"cpu_full_avg10":     float64(0),
+			"cpu_full_avg60":     float64(0),
+			"cpu_full_avg300":    float64(0),
+			"memory_some_avg10":  12.34,
+			"memory_some_avg60":  5.67,
+			"memory_some_avg300": 1.23,
+			"memory_full_avg10":  8.1,
+			"memory_full_avg60":  3.2,
+			"memory_full_avg300": 0.8,
+			"io_some_avg10":      0.25,
+			"io_some_avg60":      0.1,
+			"io_some_avg300":     0.05,
+			"io_full_avg10":      0.2,
+			"io_full_avg60":      0.08,
+			"io_full_avg300":     0.04,
+		},
+	}
+
+	pr := NewPressure()
+
+	res, err := pr.process(inputs)
+	require.NoError(t, err)
+	require.EqualValues(t, output, res)
+}
+
+func TestPressure_Unsupported(t *testing.T) {
+	pr := NewPressure()
+	pr.root = t.TempDir() + "/missing"
+
+	res, err := pr.Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, "unavailable", res.State)
+}
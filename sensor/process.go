@@ -0,0 +1,116 @@
+package sensor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+	"time"
+
+	"hacompanion/entity"
+	"hacompanion/util"
+)
+
+// Process reports whether processes matching a name or pattern are running.
+type Process struct {
+	table      *processTable
+	cpu        cpuTracker
+	name       string
+	pattern    *regexp.Regexp
+	patternErr error
+	binary     bool
+}
+
+func NewProcess(m entity.Meta) *Process {
+	p := &Process{
+		table:  procTable,
+		name:   m.GetString("name"),
+		binary: m.GetBool("binary"),
+	}
+	if pattern := m.GetString("pattern"); pattern != "" {
+		p.pattern, p.patternErr = regexp.Compile(pattern)
+	}
+	return p
+}
+
+func (pr *Process) Run(ctx context.Context) (*entity.Payload, error) {
+	if pr.patternErr != nil {
+		return nil, fmt.Errorf("invalid process pattern: %w", pr.patternErr)
+	}
+	if pr.name == "" && pr.pattern == nil {
+		return nil, errors.New("process sensor requires a name or pattern to be specified")
+	}
+	processes, takenAt, err := pr.table.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	boot, err := bootTime(pr.table.root)
+	if err != nil {
+		return nil, err
+	}
+	return pr.process(processes, boot, takenAt), nil
+}
+
+// matches returns true if the process matches the configured name or pattern.
+func (pr *Process) matches(proc processInfo) bool {
+	if pr.name != "" {
+		// The kernel truncates the name in stat to 15 characters,
+		// so the executable from the command line is checked as well.
+		var executable string
+		if fields := strings.Fields(proc.Cmdline); len(fields) > 0 {
+			executable = filepath.Base(fields[0])
+		}
+		if proc.Name == pr.name || executable == pr.name {
+			return true
+		}
+	}
+	if pr.pattern != nil {
+		cmdline := proc.Cmdline
+		if cmdline == "" {
+			cmdline = proc.Name
+		}
+		return pr.pattern.MatchString(cmdline)
+	}
+	return false
+}
+
+func (pr *Process) process(processes []processInfo, boot, takenAt time.Time) *entity.Payload {
+	usage := pr.cpu.update(processes, takenAt)
+
+	pids := make([]int, 0)
+	var rss uint64
+	var cpuPercent float64
+	var oldest uint64
+	for _, proc := range processes {
+		if !pr.matches(proc) {
+			continue
+		}
+		pids = append(pids, proc.PID)
+		rss += proc.RSS
+		if oldest == 0 || proc.StartTicks < oldest {
+			oldest = proc.StartTicks
+		}
+		cpuPercent += usage[proc.PID]
+	}
+	sort.Ints(pids)
+
+	p := entity.NewPayload()
+	if pr.binary {
+		p.State = len(pids) > 0
+		p.Attributes["count"] = len(pids)
+	} else {
+		p.State = len(pids)
+	}
+	p.Attributes["pids"] = pids
+	p.Attributes["cpu_percent"] = util.RoundToTwoDecimals(cpuPercent)
+	// Convert bytes to MB.
+	p.Attributes["memory_rss"] = util.RoundToTwoDecimals(float64(rss) / 1024 / 1024)
+	if len(pids) > 0 {
+		started := boot.Add(time.Duration(oldest) * time.Second / clockTicks)
+		p.Attributes["oldest_start"] = started.Format(time.RFC3339)
+	}
+	return p
+}
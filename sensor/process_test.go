@@ -0,0 +1,65 @@
+package sensor
+
+import (
+	"os"
+	"testing"
+	"time"
+
+	"hacompanion/entity"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestParseProcStat(t *testing.T) {
+	input := "1234 (Web Content (1)) S 1 1234 1234 0 -1 4194560 1000 0 0 0 150 50 0 0 20 0 30 0 4200 2000000000 2560 18446744073709551615 1 1 0 0 0 0 0 4096 0 0 0 0 17 3 0 0 0 0 0"
+
+	proc, err := parseProcStat(input)
+	require.NoError(t, err)
+	require.Equal(t, "Web Content (1)", proc.Name)
+	require.EqualValues(t, 200, proc.CPUTicks)
+	require.EqualValues(t, 4200, proc.StartTicks)
+	require.EqualValues(t, 2560*os.Getpagesize(), proc.RSS)
+}
+
+func TestProcess(t *testing.T) {
+	boot := time.Date(2024, 1, 1, 8, 0, 0, 0, time.UTC)
+	now := boot.Add(time.Hour)
+	first := []processInfo{
+		{PID: 10, Name: "zoom", Cmdline: "/opt/zoom/zoom", CPUTicks: 100, StartTicks: 6000, RSS: 100 * 1024 * 1024},
+		{PID: 20, Name: "ZoomWebviewHost", Cmdline: "/opt/zoom/ZoomWebviewHost --type=renderer", CPUTicks: 50, StartTicks: 3000, RSS: 50 * 1024 * 1024},
+		{PID: 30, Name: "bash", Cmdline: "/bin/bash", CPUTicks: 10, StartTicks: 100, RSS: 1024 * 1024},
+	}
+	second := []processInfo{
+		{PID: 10, Name: "zoom", Cmdline: "/opt/zoom/zoom", CPUTicks: 300, StartTicks: 6000, RSS: 100 * 1024 * 1024},
+		{PID: 20, Name: "ZoomWebviewHost", Cmdline: "/opt/zoom/ZoomWebviewHost --type=renderer", CPUTicks: 150, StartTicks: 3000, RSS: 50 * 1024 * 1024},
+		{PID: 30, Name: "bash", Cmdline: "/bin/bash", CPUTicks: 10, StartTicks: 100, RSS: 1024 * 1024},
+	}
+	output := &entity.Payload{
+		State: 2,
+		Attributes: map[string]interface{}{
+			"pids":         []int{10, 20},
+			"cpu_percent":  float64(30),
+			"memory_rss":   float64(150),
+			"oldest_start": boot.Add(30 * time.Second).Format(time.RFC3339),
+		},
+	}
+
+	p := NewProcess(entity.Meta{"pattern": "^/opt/zoom/"})
+
+	p.process(first, boot, now)
+	res := p.process(second, boot, now.Add(10*time.Second))
+	require.EqualValues(t, output, res)
+}
+
+func TestProcess_Binary(t *testing.T) {
+	processes := []processInfo{
+		{PID: 42, Name: "restic", Cmdline: "/usr/local/bin/restic backup /home", StartTicks: 100},
+	}
+
+	p := NewProcess(entity.Meta{"name": "restic", "binary": true})
+
+	res := p.process(processes, time.Now(), time.Now())
+	require.Equal(t, true, res.State)
+	require.Equal(t, 1, res.Attributes["count"])
+	require.Equal(t, []int{42}, res.Attributes["pids"])
+}
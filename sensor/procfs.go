@@ -0,0 +1,191 @@
+package sensor
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+)
+
+// clockTicks is the number of clock ticks per second (USER_HZ) used by the
+// kernel for all times reported in /proc. It is 100 on all supported platforms.
+const clockTicks = 100
+
+// processSnapshotMaxAge is the time a process listing is shared between
+// sensors before /proc is read again. It is shorter than any sensible
+// update interval, so all sensors of a single update share one listing.
+const processSnapshotMaxAge = 2 * time.Second
+
+// procTable is the process listing shared by all sensors.
+var procTable = newProcessTable("/proc")
+
+// processTable caches the processes read from a procfs root.
+type processTable struct {
+	root      string
+	mu        sync.Mutex
+	processes []processInfo
+	takenAt   time.Time
+}
+
+func newProcessTable(root string) *processTable {
+	return &processTable{root: root}
+}
+
+// snapshot returns the current process listing and the time it was taken.
+// The listing is only read again if the cached one is outdated.
+func (t *processTable) snapshot() ([]processInfo, time.Time, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if time.Since(t.takenAt) < processSnapshotMaxAge {
+		return t.processes, t.takenAt, nil
+	}
+	processes, err := listProcesses(t.root)
+	if err != nil {
+		return nil, time.Time{}, err
+	}
+	t.processes = processes
+	t.takenAt = time.Now()
+	return t.processes, t.takenAt, nil
+}
+
+// processInfo contains the data of a single process as read from /proc/<pid>.
+type processInfo struct {
+	PID     int
+	UID     uint32
+	Name    string
+	Cmdline string
+	// CPUTicks is the sum of the user and system time the process was scheduled for.
+	CPUTicks uint64
+	// StartTicks is the time the process was started, in clock ticks after boot.
+	StartTicks uint64
+	// RSS is the resident set size in bytes.
+	RSS uint64
+}
+
+// listProcesses reads all processes from the given procfs root.
+// Processes that vanish while they are being read are skipped.
+func listProcesses(root string) ([]processInfo, error) {
+	entries, err := os.ReadDir(root)
+	if err != nil {
+		return nil, err
+	}
+	var processes []processInfo
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || !entry.IsDir() {
+			continue
+		}
+		stat, err := os.ReadFile(filepath.Join(root, entry.Name(), "stat"))
+		if err != nil {
+			continue
+		}
+		proc, err := parseProcStat(string(stat))
+		if err != nil {
+			continue
+		}
+		proc.PID = pid
+		// The owner of the process directory is the effective user of the process.
+		if info, err := entry.Info(); err == nil {
+			if sys, ok := info.Sys().(*syscall.Stat_t); ok {
+				proc.UID = sys.Uid
+			}
+		}
+		if cmdline, err := os.ReadFile(filepath.Join(root, entry.Name(), "cmdline")); err == nil {
+			proc.Cmdline = strings.TrimSpace(strings.ReplaceAll(string(cmdline), "\x00", " "))
+		}
+		processes = append(processes, proc)
+	}
+	return processes, nil
+}
+
+// parseProcStat parses the content of a /proc/<pid>/stat file.
+func parseProcStat(output string) (processInfo, error) {
+	var proc processInfo
+	// The process name is wrapped in parentheses and may contain spaces
+	// and parentheses itself, so everything up to the last ")" belongs to it.
+	start := strings.IndexByte(output, '(')
+	end := strings.LastIndexByte(output, ')')
+	if start < 0 || end < start {
+		return proc, fmt.Errorf("invalid stat format: %s", output)
+	}
+	proc.Name = output[start+1 : end]
+	// The fields after the name start with the state, which is field 3 in proc(5).
+	fields := strings.Fields(output[end+1:])
+	if len(fields) < 22 {
+		return proc, fmt.Errorf("expected at least 24 fields in stat, got %d", len(fields)+2)
+	}
+	values := make(map[int]uint64)
+	for _, field := range []int{14, 15, 22, 24} {
+		value, err := strconv.ParseUint(fields[field-3], 10, 64)
+		if err != nil {
+			return proc, fmt.Errorf("failed to parse stat field %d: %w", field, err)
+		}
+		values[field] = value
+	}
+	proc.CPUTicks = values[14] + values[15]
+	proc.StartTicks = values[22]
+	proc.RSS = values[24] * uint64(os.Getpagesize())
+	return proc, nil
+}
+
+// bootTime returns the system boot time as reported by the btime field in /proc/stat.
+func bootTime(root string) (time.Time, error) {
+	f, err := os.Open(filepath.Join(root, "stat"))
+	if err != nil {
+		return time.Time{}, err
+	}
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 || fields[0] != "btime" {
+			continue
+		}
+		seconds, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			return time.Time{}, fmt.Errorf("failed to parse btime %s: %w", fields[1], err)
+		}
+		return time.Unix(seconds, 0), nil
+	}
+	return time.Time{}, fmt.Errorf("could not find btime in %s", f.Name())
+}
+
+// cpuTracker calculates the CPU usage of processes between two snapshots.
+type cpuTracker struct {
+	mu        sync.Mutex
+	last      map[int]processInfo
+	lastAt    time.Time
+	lastUsage map[int]float64
+}
+
+// update stores the given snapshot and returns the CPU usage in percent of a
+// single core for every process that was already present in the previous one.
+func (c *cpuTracker) update(processes []processInfo, takenAt time.Time) map[int]float64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	// The shared snapshot did not change since the last call.
+	if !takenAt.After(c.lastAt) {
+		return c.lastUsage
+	}
+	usage := make(map[int]float64)
+	elapsed := takenAt.Sub(c.lastAt).Seconds()
+	current := make(map[int]processInfo, len(processes))
+	for _, proc := range processes {
+		current[proc.PID] = proc
+		// A different start time means the PID was reused by a new process.
+		last, ok := c.last[proc.PID]
+		if !ok || last.StartTicks != proc.StartTicks || proc.CPUTicks < last.CPUTicks {
+			continue
+		}
+		usage[proc.PID] = float64(proc.CPUTicks-last.CPUTicks) / clockTicks / elapsed * 100
+	}
+	c.last = current
+	c.lastAt = takenAt
+	c.lastUsage = usage
+	return usage
+}
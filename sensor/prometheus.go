@@ -0,0 +1,396 @@
+package sensor
+
+import (
+	"bufio"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"net/http"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"hacompanion/entity"
+	"hacompanion/util"
+)
+
+// Prometheus reports metrics scraped from an endpoint in the Prometheus text exposition format.
+// The state and attributes are selected with PromQL-like selectors such as
+// node_filesystem_avail_bytes{mountpoint="/"}. Counters are reported as rates per second.
+type Prometheus struct {
+	url        string
+	state      promSelector
+	attributes map[string]promSelector
+	scale      float64
+	client     http.Client
+	now        func() time.Time
+	// err is set if the configuration is invalid and returned on every run.
+	err error
+
+	mu sync.Mutex
+	// previous contains the counter values of the last scrape to calculate rates.
+	previous   map[string]float64
+	previousAt time.Time
+}
+
+func NewPrometheus(m entity.Meta) *Prometheus {
+	p := &Prometheus{
+		url:        m.GetString("url"),
+		attributes: make(map[string]promSelector),
+		scale:      m.GetFloat("scale"),
+		client:     http.Client{Timeout: 10 * time.Second},
+		now:        time.Now,
+		previous:   make(map[string]float64),
+	}
+	if p.url == "" {
+		p.err = errors.New("prometheus sensor requires url to be specified")
+		return p
+	}
+	p.state, p.err = parsePromSelector(m.GetString("metric"))
+	if p.err != nil {
+		return p
+	}
+	for name, expr := range m.GetStringMap("attributes") {
+		selector, err := parsePromSelector(expr)
+		if err != nil {
+			p.err = err
+			return p
+		}
+		p.attributes[name] = selector
+	}
+	return p
+}
+
+// promSample is a single sample of a scraped series.
+type promSample struct {
+	name   string
+	labels map[string]string
+	value  float64
+}
+
+// key identifies the series of a sample.
+func (s promSample) key() string {
+	names := make([]string, 0, len(s.labels))
+	for name := range s.labels {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	var b strings.Builder
+	b.WriteString(s.name)
+	for _, name := range names {
+		fmt.Fprintf(&b, ",%s=%q", name, s.labels[name])
+	}
+	return b.String()
+}
+
+// promScrape is the parsed result of a scrape.
+type promScrape struct {
+	samples []promSample
+	// types contains the metric types from the TYPE comments.
+	types map[string]string
+}
+
+// isCounter reports if a sample is monotonically increasing.
+// The _sum and _count series of histograms and summaries are counters as well.
+func (s promScrape) isCounter(name string) bool {
+	if s.types[name] == "counter" || s.types[strings.TrimSuffix(name, "_total")] == "counter" {
+		return true
+	}
+	for _, suffix := range []string{"_sum", "_count", "_bucket"} {
+		if base, ok := strings.CutSuffix(name, suffix); ok {
+			if t := s.types[base]; t == "histogram" || t == "summary" {
+				return true
+			}
+		}
+	}
+	return false
+}
+
+// promMatcher matches a single label of a series.
+type promMatcher struct {
+	label string
+	op    string
+	value string
+	re    *regexp.Regexp
+}
+
+func (m promMatcher) matches(labels map[string]string) bool {
+	value := labels[m.label]
+	switch m.op {
+	case "=":
+		return value == m.value
+	case "!=":
+		return value != m.value
+	case "=~":
+		return m.re.MatchString(value)
+	case "!~":
+		return !m.re.MatchString(value)
+	}
+	return false
+}
+
+// promSelector selects series by their name and labels.
+type promSelector struct {
+	expr     string
+	name     string
+	matchers []promMatcher
+}
+
+func (s promSelector) matches(sample promSample) bool {
+	if s.name != "" && s.name != sample.name {
+		return false
+	}
+	for _, m := range s.matchers {
+		if m.label == "__name__" {
+			if !m.matches(map[string]string{"__name__": sample.name}) {
+				return false
+			}
+			continue
+		}
+		if !m.matches(sample.labels) {
+			return false
+		}
+	}
+	return true
+}
+
+func parsePromSelector(expr string) (promSelector, error) {
+	expr = strings.TrimSpace(expr)
+	if expr == "" {
+		return promSelector{}, errors.New("prometheus sensor requires metric to be specified")
+	}
+	selector := promSelector{expr: expr}
+	name, rest, hasLabels := strings.Cut(expr, "{")
+	selector.name = strings.TrimSpace(name)
+	if !hasLabels {
+		return selector, nil
+	}
+	matchers, rest, err := parsePromLabels(rest, true)
+	if err != nil {
+		return selector, fmt.Errorf("invalid selector %s: %w", expr, err)
+	}
+	if strings.TrimSpace(rest) != "" {
+		return selector, fmt.Errorf("invalid selector %s: unexpected %s", expr, rest)
+	}
+	for i, m := range matchers {
+		if m.op == "=~" || m.op == "!~" {
+			// Regular expressions are fully anchored, as in PromQL.
+			matchers[i].re, err = regexp.Compile("^(?:" + m.value + ")$")
+			if err != nil {
+				return selector, fmt.Errorf("invalid selector %s: %w", expr, err)
+			}
+		}
+	}
+	selector.matchers = matchers
+	return selector, nil
+}
+
+// parsePromLabels parses a label list following the opening brace and returns the
+// remaining input after the closing brace. Only equality is allowed if withOps is false.
+func parsePromLabels(input string, withOps bool) ([]promMatcher, string, error) {
+	var matchers []promMatcher
+	rest := input
+	for {
+		rest = strings.TrimLeft(rest, " \t,")
+		if strings.HasPrefix(rest, "}") {
+			return matchers, rest[1:], nil
+		}
+		end := strings.IndexAny(rest, "=!")
+		if end <= 0 {
+			return nil, "", errors.New("expected label name")
+		}
+		m := promMatcher{label: strings.TrimSpace(rest[:end])}
+		rest = rest[end:]
+		for _, op := range []string{"=~", "!~", "!=", "="} {
+			if strings.HasPrefix(rest, op) {
+				m.op = op
+				break
+			}
+		}
+		if m.op == "" || (!withOps && m.op != "=") {
+			return nil, "", fmt.Errorf("invalid operator for label %s", m.label)
+		}
+		rest = strings.TrimLeft(rest[len(m.op):], " \t")
+		value, remaining, err := parsePromString(rest)
+		if err != nil {
+			return nil, "", fmt.Errorf("invalid value for label %s: %w", m.label, err)
+		}
+		m.value = value
+		matchers = append(matchers, m)
+		rest = remaining
+	}
+}
+
+// parsePromString parses a double-quoted string with backslash escapes.
+func parsePromString(input string) (string, string, error) {
+	if !strings.HasPrefix(input, `"`) {
+		return "", "", errors.New("expected quoted string")
+	}
+	var b strings.Builder
+	for i := 1; i < len(input); i++ {
+		switch c := input[i]; c {
+		case '\\':
+			if i+1 >= len(input) {
+				return "", "", errors.New("unterminated string")
+			}
+			i++
+			switch input[i] {
+			case 'n':
+				b.WriteByte('\n')
+			default:
+				b.WriteByte(input[i])
+			}
+		case '"':
+			return b.String(), input[i+1:], nil
+		default:
+			b.WriteByte(c)
+		}
+	}
+	return "", "", errors.New("unterminated string")
+}
+
+// parsePrometheus parses the Prometheus text exposition format.
+func parsePrometheus(r io.Reader) (promScrape, error) {
+	scrape := promScrape{types: make(map[string]string)}
+	scanner := bufio.NewScanner(r)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" {
+			continue
+		}
+		if strings.HasPrefix(line, "#") {
+			fields := strings.Fields(line)
+			if len(fields) >= 4 && fields[1] == "TYPE" {
+				scrape.types[fields[2]] = fields[3]
+			}
+			continue
+		}
+		sample, err := parsePromSample(line)
+		if err != nil {
+			return scrape, fmt.Errorf("failed to parse line %q: %w", line, err)
+		}
+		scrape.samples = append(scrape.samples, sample)
+	}
+	return scrape, scanner.Err()
+}
+
+func parsePromSample(line string) (promSample, error) {
+	sample := promSample{labels: make(map[string]string)}
+	end := strings.IndexAny(line, "{ \t")
+	if end <= 0 {
+		return sample, errors.New("expected metric name")
+	}
+	sample.name = line[:end]
+	rest := line[end:]
+	if strings.HasPrefix(rest, "{") {
+		labels, remaining, err := parsePromLabels(rest[1:], false)
+		if err != nil {
+			return sample, err
+		}
+		for _, label := range labels {
+			sample.labels[label.label] = label.value
+		}
+		rest = remaining
+	}
+	// The value may be followed by a timestamp, which is ignored.
+	fields := strings.Fields(rest)
+	if len(fields) == 0 {
+		return sample, errors.New("missing value")
+	}
+	value, err := strconv.ParseFloat(fields[0], 64)
+	if err != nil {
+		return sample, err
+	}
+	sample.value = value
+	return sample, nil
+}
+
+func (p *Prometheus) Run(ctx context.Context) (*entity.Payload, error) {
+	if p.err != nil {
+		return nil, p.err
+	}
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, p.url, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "HomeAssistant-Companion/Prometheus")
+	req.Header.Set("Accept", "text/plain;version=0.0.4")
+	resp, err := p.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("scraping %s failed with status %s", p.url, resp.Status)
+	}
+	scrape, err := parsePrometheus(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	return p.process(scrape, p.now())
+}
+
+func (p *Prometheus) process(scrape promScrape, now time.Time) (*entity.Payload, error) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+
+	elapsed := now.Sub(p.previousAt).Seconds()
+	current := make(map[string]float64)
+	// value returns the sum of all series matching the selector. Counters are
+	// summed up as rates, which are only known from the second scrape on.
+	value := func(selector promSelector) (sum float64, matched, known bool) {
+		for _, sample := range scrape.samples {
+			if !selector.matches(sample) {
+				continue
+			}
+			matched = true
+			if !scrape.isCounter(sample.name) {
+				sum += sample.value
+				known = true
+				continue
+			}
+			key := sample.key()
+			current[key] = sample.value
+			previous, ok := p.previous[key]
+			if !ok || elapsed <= 0 {
+				continue
+			}
+			increase := sample.value - previous
+			if increase < 0 {
+				// The counter was reset, e.g. by restarting the service.
+				increase = sample.value
+			}
+			sum += increase / elapsed
+			known = true
+		}
+		return sum, matched, known
+	}
+
+	payload := entity.NewPayload()
+	state, matched, known := value(p.state)
+	if !matched {
+		return nil, fmt.Errorf("no series matches %s", p.state.expr)
+	}
+	switch {
+	case !known || math.IsNaN(state) || math.IsInf(state, 0):
+		payload.State = "unavailable"
+	case p.scale != 0:
+		payload.State = util.RoundToTwoDecimals(state * p.scale)
+	default:
+		payload.State = util.RoundToTwoDecimals(state)
+	}
+	for name, selector := range p.attributes {
+		if v, _, known := value(selector); known && !math.IsNaN(v) && !math.IsInf(v, 0) {
+			payload.Attributes[name] = util.RoundToTwoDecimals(v)
+		}
+	}
+	p.previous = current
+	p.previousAt = now
+	return payload, nil
+}
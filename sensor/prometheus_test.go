@@ -0,0 +1,136 @@
+package sensor
+
+import (
+	"context"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"hacompanion/entity"
+
+	"github.com/stretchr/testify/require"
+)
+
+const prometheusOutput = `# HELP node_filesystem_avail_bytes Filesystem space available to non-root users in bytes.
+# TYPE node_filesystem_avail_bytes gauge
+node_filesystem_avail_bytes{device="/dev/nvme0n1p3",fstype="btrfs",mountpoint="/"} 1.2345e+11
+node_filesystem_avail_bytes{device="/dev/nvme0n1p3",fstype="btrfs",mountpoint="/home"} 1.2345e+11
+node_filesystem_avail_bytes{device="tmpfs",fstype="tmpfs",mountpoint="/tmp"} 8.1e+09
+# HELP http_requests_total Total HTTP requests.
+# TYPE http_requests_total counter
+http_requests_total{code="200",handler="/api"} %d 1718000000000
+http_requests_total{code="500",handler="/api"} %d
+http_requests_total{code="200",handler="/metrics",path="a \"quoted\" \\ value"} 10
+# TYPE request_duration_seconds histogram
+request_duration_seconds_bucket{le="+Inf"} 4
+request_duration_seconds_sum 1.5
+request_duration_seconds_count %d
+up NaN
+`
+
+func prometheusScrape(t *testing.T, ok, failed int) promScrape {
+	t.Helper()
+	scrape, err := parsePrometheus(strings.NewReader(fmt.Sprintf(prometheusOutput, ok, failed, ok+failed)))
+	require.NoError(t, err)
+	return scrape
+}
+
+func TestParsePrometheus(t *testing.T) {
+	scrape := prometheusScrape(t, 100, 5)
+	require.Len(t, scrape.samples, 10)
+	require.Equal(t, promSample{
+		name:   "http_requests_total",
+		labels: map[string]string{"code": "200", "handler": "/metrics", "path": `a "quoted" \ value`},
+		value:  10,
+	}, scrape.samples[5])
+	require.True(t, scrape.isCounter("http_requests_total"))
+	require.True(t, scrape.isCounter("request_duration_seconds_count"))
+	require.False(t, scrape.isCounter("node_filesystem_avail_bytes"))
+
+	_, err := parsePrometheus(strings.NewReader(`broken{label="value} 1`))
+	require.Error(t, err)
+}
+
+func TestPrometheusSelectors(t *testing.T) {
+	scrape := prometheusScrape(t, 100, 5)
+	cases := []struct {
+		expr    string
+		matches int
+	}{
+		{`node_filesystem_avail_bytes`, 3},
+		{`node_filesystem_avail_bytes{mountpoint="/"}`, 1},
+		{`node_filesystem_avail_bytes{fstype!="tmpfs"}`, 2},
+		{`node_filesystem_avail_bytes{mountpoint=~"/h.*", device!~"tmp.*"}`, 1},
+		{`{__name__=~"http_.*", code="200"}`, 2},
+		{`missing_metric`, 0},
+	}
+	for _, tc := range cases {
+		selector, err := parsePromSelector(tc.expr)
+		require.NoError(t, err, tc.expr)
+		var matches int
+		for _, sample := range scrape.samples {
+			if selector.matches(sample) {
+				matches++
+			}
+		}
+		require.Equal(t, tc.matches, matches, tc.expr)
+	}
+
+	for _, expr := range []string{``, `metric{label}`, `metric{label="value"`, `metric{label=~"("}`} {
+		_, err := parsePromSelector(expr)
+		require.Error(t, err, expr)
+	}
+}
+
+func TestPrometheusRates(t *testing.T) {
+	p := NewPrometheus(entity.Meta{
+		"url":    "http://localhost:9100/metrics",
+		"metric": `http_requests_total{handler="/api"}`,
+		"attributes": map[string]interface{}{
+			"errors":        `http_requests_total{code=~"5.."}`,
+			"root_free":     `node_filesystem_avail_bytes{mountpoint="/"}`,
+			"requests":      `request_duration_seconds_count`,
+			"missing_value": `up`,
+		},
+	})
+	require.NoError(t, p.err)
+	start := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+
+	// Rates are unknown after the first scrape.
+	payload, err := p.process(prometheusScrape(t, 100, 5), start)
+	require.NoError(t, err)
+	require.Equal(t, "unavailable", payload.State)
+	require.EqualValues(t, map[string]interface{}{"root_free": 1.2345e+11}, payload.Attributes)
+
+	payload, err = p.process(prometheusScrape(t, 700, 65), start.Add(time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, 11.0, payload.State)
+	require.EqualValues(t, map[string]interface{}{"root_free": 1.2345e+11, "errors": 1.0, "requests": 11.0}, payload.Attributes)
+
+	// Counter resets start over from zero.
+	payload, err = p.process(prometheusScrape(t, 30, 0), start.Add(2*time.Minute))
+	require.NoError(t, err)
+	require.Equal(t, 0.5, payload.State)
+}
+
+func TestPrometheusRun(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		_, _ = fmt.Fprintf(w, prometheusOutput, 1, 2, 3)
+	}))
+	defer server.Close()
+
+	p := NewPrometheus(entity.Meta{"url": server.URL, "metric": `node_filesystem_avail_bytes{fstype="btrfs"}`, "scale": 1e-9})
+	payload, err := p.Run(context.Background())
+	require.NoError(t, err)
+	require.Equal(t, 246.9, payload.State)
+
+	p = NewPrometheus(entity.Meta{"url": server.URL, "metric": `missing_metric`})
+	_, err = p.Run(context.Background())
+	require.EqualError(t, err, "no series matches missing_metric")
+
+	_, err = NewPrometheus(entity.Meta{"metric": "up"}).Run(context.Background())
+	require.Error(t, err)
+}
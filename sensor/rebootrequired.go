@@ -0,0 +1,196 @@
+package sensor
+
+import (
+	"bufio"
+	"bytes"
+	"context"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"syscall"
+	"time"
+	"unicode"
+
+	"hacompanion/entity"
+)
+
+// rpmRebootPackages are the packages that require a reboot after an update.
+// This is the same list that is used by dnf's needs-restarting.
+var rpmRebootPackages = []string{
+	"kernel", "kernel-core", "kernel-rt", "glibc", "linux-firmware",
+	"systemd", "dbus", "dbus-broker", "dbus-daemon", "microcode_ctl",
+	"openssl-libs", "gnutls", "zlib",
+}
+
+// RebootRequired reports if the system has to be rebooted to apply updates.
+type RebootRequired struct {
+	root string
+}
+
+func NewRebootRequired() *RebootRequired {
+	return &RebootRequired{root: "/"}
+}
+
+func (r RebootRequired) Run(ctx context.Context) (*entity.Payload, error) {
+	var uname syscall.Utsname
+	if err := syscall.Uname(&uname); err != nil {
+		return nil, err
+	}
+	running := utsnameToString(uname.Release[:])
+
+	var reasons, packages []string
+	// Debian based systems create a flag file when an update requires a reboot.
+	if _, err := os.Stat(filepath.Join(r.root, "var/run/reboot-required")); err == nil {
+		reasons = append(reasons, "updates require a reboot")
+		packages = append(packages, r.debianPackages()...)
+	}
+	// RPM based systems are checked by the install time of the core packages.
+	if _, err := exec.LookPath("rpm"); err == nil {
+		if updated := r.rpmUpdatedPackages(ctx); len(updated) > 0 {
+			reasons = append(reasons, "core packages were updated since boot")
+			packages = append(packages, updated...)
+		}
+	}
+	// Every distribution installs the kernel modules to /lib/modules.
+	newest, kernelReason := r.kernelMismatch(running)
+	if kernelReason != "" {
+		reasons = append(reasons, kernelReason)
+	}
+
+	p := entity.NewPayload()
+	p.State = len(reasons) > 0
+	p.Attributes["reason"] = strings.Join(reasons, ", ")
+	p.Attributes["packages"] = uniqueSorted(packages)
+	p.Attributes["running_kernel"] = running
+	if newest != "" {
+		p.Attributes["installed_kernel"] = newest
+	}
+	if len(reasons) > 0 {
+		p.Icon = "mdi:restart-alert"
+	}
+	return p, nil
+}
+
+// debianPackages returns the packages listed in the reboot-required.pkgs file.
+func (r RebootRequired) debianPackages() []string {
+	b, err := os.ReadFile(filepath.Join(r.root, "var/run/reboot-required.pkgs"))
+	if err != nil {
+		return nil
+	}
+	return strings.Fields(string(b))
+}
+
+// rpmUpdatedPackages returns the core packages that were installed after the last boot.
+func (r RebootRequired) rpmUpdatedPackages(ctx context.Context) []string {
+	boot, err := bootTime(filepath.Join(r.root, "proc"))
+	if err != nil {
+		return nil
+	}
+	var out bytes.Buffer
+	args := append([]string{"-q", "--queryformat", "%{NAME} %{INSTALLTIME}\n"}, rpmRebootPackages...)
+	cmd := exec.CommandContext(ctx, "rpm", args...)
+	cmd.Stdout = &out
+	// rpm exits with an error if any of the packages is not installed, the output is still usable.
+	_ = cmd.Run()
+	return r.processRPMInstallTimes(out.String(), boot)
+}
+
+func (r RebootRequired) processRPMInstallTimes(output string, boot time.Time) []string {
+	var packages []string
+	scanner := bufio.NewScanner(strings.NewReader(output))
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) != 2 {
+			continue
+		}
+		installed, err := strconv.ParseInt(fields[1], 10, 64)
+		if err != nil {
+			continue
+		}
+		if time.Unix(installed, 0).After(boot) {
+			packages = append(packages, fields[0])
+		}
+	}
+	return packages
+}
+
+// kernelMismatch compares the running kernel to the kernels installed in /lib/modules.
+func (r RebootRequired) kernelMismatch(running string) (newest, reason string) {
+	entries, err := os.ReadDir(filepath.Join(r.root, "lib/modules"))
+	if err != nil {
+		return "", ""
+	}
+	var installed []string
+	for _, entry := range entries {
+		// Only directories with a modules.dep file belong to an installed kernel,
+		// others may be left over from out-of-tree modules.
+		if _, err := os.Stat(filepath.Join(r.root, "lib/modules", entry.Name(), "modules.dep")); err == nil {
+			installed = append(installed, entry.Name())
+		}
+	}
+	if len(installed) == 0 {
+		return "", ""
+	}
+	newest = installed[0]
+	var runningInstalled bool
+	for _, version := range installed {
+		if version == running {
+			runningInstalled = true
+		}
+		if compareVersions(version, newest) > 0 {
+			newest = version
+		}
+	}
+	switch {
+	case !runningInstalled:
+		// Rolling distributions remove the modules of the running kernel on upgrade.
+		return newest, "running kernel is no longer installed"
+	case compareVersions(newest, running) > 0:
+		return newest, "a newer kernel is installed"
+	}
+	return newest, ""
+}
+
+// compareVersions compares two version strings segment by segment,
+// numerical segments are compared by their value.
+func compareVersions(a, b string) int {
+	as, bs := versionSegments(a), versionSegments(b)
+	for i := 0; i < len(as) && i < len(bs); i++ {
+		an, aErr := strconv.Atoi(as[i])
+		bn, bErr := strconv.Atoi(bs[i])
+		switch {
+		case aErr == nil && bErr == nil:
+			if an != bn {
+				if an > bn {
+					return 1
+				}
+				return -1
+			}
+		case as[i] != bs[i]:
+			return strings.Compare(as[i], bs[i])
+		}
+	}
+	return len(as) - len(bs)
+}
+
+// versionSegments splits a version into numerical and non-numerical segments.
+func versionSegments(version string) []string {
+	var segments []string
+	var current []rune
+	for _, c := range version {
+		separator := c == '.' || c == '-' || c == '_' || c == '+' || c == '~'
+		if len(current) > 0 && (separator || unicode.IsDigit(c) != unicode.IsDigit(current[0])) {
+			segments = append(segments, string(current))
+			current = nil
+		}
+		if !separator {
+			current = append(current, c)
+		}
+	}
+	if len(current) > 0 {
+		segments = append(segments, string(current))
+	}
+	return segments
+}
@@ -0,0 +1,63 @@
+package sensor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRebootRequiredKernelMismatch(t *testing.T) {
+	cases := []struct {
+		name      string
+		installed []string
+		running   string
+		newest    string
+		reason    string
+	}{
+		{"up to date", []string{"6.8.11-300.fc40.x86_64", "6.9.7-200.fc40.x86_64"}, "6.9.7-200.fc40.x86_64", "6.9.7-200.fc40.x86_64", ""},
+		{"newer kernel", []string{"6.9.7-200.fc40.x86_64", "6.9.10-100.fc40.x86_64"}, "6.9.7-200.fc40.x86_64", "6.9.10-100.fc40.x86_64", "a newer kernel is installed"},
+		{"running removed", []string{"6.9.10-arch1-1"}, "6.9.7-arch1-1", "6.9.10-arch1-1", "running kernel is no longer installed"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			root := t.TempDir()
+			for _, version := range tc.installed {
+				dir := filepath.Join(root, "lib/modules", version)
+				require.NoError(t, os.MkdirAll(dir, 0o755))
+				require.NoError(t, os.WriteFile(filepath.Join(dir, "modules.dep"), nil, 0o600))
+			}
+			// Directories without modules.dep are ignored.
+			require.NoError(t, os.MkdirAll(filepath.Join(root, "lib/modules/7.0.0-extra"), 0o755))
+			newest, reason := RebootRequired{root: root}.kernelMismatch(tc.running)
+			require.Equal(t, tc.newest, newest)
+			require.Equal(t, tc.reason, reason)
+		})
+	}
+}
+
+func TestRebootRequiredDebianPackages(t *testing.T) {
+	root := t.TempDir()
+	require.NoError(t, os.MkdirAll(filepath.Join(root, "var/run"), 0o755))
+	require.NoError(t, os.WriteFile(filepath.Join(root, "var/run/reboot-required.pkgs"), []byte("linux-image-6.8.0-40-generic\nlibc6\n"), 0o600))
+	require.EqualValues(t, []string{"linux-image-6.8.0-40-generic", "libc6"}, RebootRequired{root: root}.debianPackages())
+}
+
+func TestRebootRequiredRPMInstallTimes(t *testing.T) {
+	boot := time.Unix(1720000000, 0)
+	output := `kernel-core 1720100000
+glibc 1710000000
+package zlib is not installed
+systemd 1720000001
+`
+	require.EqualValues(t, []string{"kernel-core", "systemd"}, RebootRequired{}.processRPMInstallTimes(output, boot))
+}
+
+func TestCompareVersions(t *testing.T) {
+	require.Positive(t, compareVersions("6.9.10-100.fc40", "6.9.7-200.fc40"))
+	require.Negative(t, compareVersions("6.8.0-40-generic", "6.8.0-45-generic"))
+	require.Zero(t, compareVersions("6.9.7-arch1-1", "6.9.7-arch1-1"))
+	require.Positive(t, compareVersions("6.10.0-rc1", "6.9.12"))
+}
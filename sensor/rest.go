@@ -0,0 +1,128 @@
+package sensor
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"strings"
+	"time"
+
+	"hacompanion/entity"
+)
+
+// Rest reports values from the JSON response of an HTTP endpoint.
+type Rest struct {
+	url     string
+	method  string
+	headers map[string]string
+	body    string
+	// tokenEnv is the environment variable that contains a bearer token.
+	tokenEnv   string
+	state      string
+	attributes map[string]string
+	client     http.Client
+}
+
+func NewRest(m entity.Meta) *Rest {
+	r := &Rest{
+		url:        m.GetString("url"),
+		method:     http.MethodGet,
+		headers:    m.GetStringMap("headers"),
+		body:       m.GetString("body"),
+		tokenEnv:   m.GetString("token_env"),
+		state:      m.GetString("state"),
+		attributes: m.GetStringMap("attributes"),
+		client:     http.Client{Timeout: 10 * time.Second},
+	}
+	if method := m.GetString("method"); method != "" {
+		r.method = strings.ToUpper(method)
+	}
+	if timeout, err := time.ParseDuration(m.GetString("timeout")); err == nil && timeout > 0 {
+		r.client.Timeout = timeout
+	}
+	return r
+}
+
+func (r Rest) Run(ctx context.Context) (*entity.Payload, error) {
+	if r.url == "" {
+		return nil, fmt.Errorf("rest sensor requires url to be specified")
+	}
+	var body io.Reader
+	if r.body != "" {
+		body = strings.NewReader(r.body)
+	}
+	req, err := http.NewRequestWithContext(ctx, r.method, r.url, body)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("User-Agent", "HomeAssistant-Companion/Rest")
+	req.Header.Set("Accept", "application/json")
+	if r.body != "" {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if r.tokenEnv != "" {
+		token := os.Getenv(r.tokenEnv)
+		if token == "" {
+			return nil, fmt.Errorf("environment variable %s is not set", r.tokenEnv)
+		}
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+	// Configured headers take precedence over the defaults.
+	for key, value := range r.headers {
+		req.Header.Set(key, value)
+	}
+
+	resp, err := r.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	content, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	if resp.StatusCode < http.StatusOK || resp.StatusCode >= http.StatusMultipleChoices {
+		return nil, fmt.Errorf("request to %s failed with status %s", r.url, resp.Status)
+	}
+	return r.process(content)
+}
+
+func (r Rest) process(content []byte) (*entity.Payload, error) {
+	p := entity.NewPayload()
+	// Without any expressions, the response doesn't have to be JSON.
+	if r.state == "" && len(r.attributes) == 0 {
+		p.State = strings.TrimSpace(string(content))
+		return p, nil
+	}
+	var doc interface{}
+	if err := json.Unmarshal(content, &doc); err != nil {
+		return nil, fmt.Errorf("failed to parse response: %w", err)
+	}
+	state, err := jsonValue(doc, r.state)
+	if err != nil {
+		return nil, err
+	}
+	switch value := state.(type) {
+	case map[string]interface{}, []interface{}:
+		// Home Assistant only supports scalar states.
+		b, err := json.Marshal(value)
+		if err != nil {
+			return nil, err
+		}
+		p.State = string(b)
+	default:
+		p.State = value
+	}
+	for name, expr := range r.attributes {
+		value, err := jsonValue(doc, expr)
+		if err != nil {
+			// Missing attributes don't fail the whole sensor.
+			continue
+		}
+		p.Attributes[name] = value
+	}
+	return p, nil
+}
@@ -0,0 +1,105 @@
+package sensor
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"hacompanion/entity"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestRest(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/completion":
+			if r.Header.Get("X-API-Key") != "secret" {
+				w.WriteHeader(http.StatusForbidden)
+				return
+			}
+			_, _ = w.Write([]byte(`{"completion":99.5,"needBytes":1024,"remoteState":"valid"}`))
+		case "/search":
+			body, _ := io.ReadAll(r.Body)
+			if r.Method != http.MethodPost || r.Header.Get("Authorization") != "Bearer token" || string(body) != `{"q":"jobs"}` {
+				w.WriteHeader(http.StatusBadRequest)
+				return
+			}
+			_, _ = w.Write([]byte(`{"printers":[{"name":"office","jobs":["a","b"],"a/b":true}]}`))
+		case "/version":
+			_, _ = w.Write([]byte("1.2.3\n"))
+		}
+	}))
+	defer server.Close()
+	t.Setenv("REST_TEST_TOKEN", "token")
+
+	cases := []struct {
+		name       string
+		meta       entity.Meta
+		state      interface{}
+		attributes map[string]interface{}
+	}{
+		{
+			name: "dotted path",
+			meta: entity.Meta{
+				"url":        server.URL + "/completion",
+				"headers":    map[string]interface{}{"X-API-Key": "secret"},
+				"state":      "completion",
+				"attributes": map[string]interface{}{"need_bytes": "needBytes", "missing": "nope"},
+			},
+			state:      99.5,
+			attributes: map[string]interface{}{"need_bytes": 1024.0},
+		},
+		{
+			name: "json pointer",
+			meta: entity.Meta{
+				"url":        server.URL + "/search",
+				"method":     "post",
+				"body":       `{"q":"jobs"}`,
+				"token_env":  "REST_TEST_TOKEN",
+				"state":      "/printers/0/name",
+				"attributes": map[string]interface{}{"jobs": "/printers/0/jobs", "escaped": "/printers/0/a~1b"},
+			},
+			state:      "office",
+			attributes: map[string]interface{}{"jobs": []interface{}{"a", "b"}, "escaped": true},
+		},
+		{
+			name:       "object state",
+			meta:       entity.Meta{"url": server.URL + "/search", "method": "POST", "body": `{"q":"jobs"}`, "token_env": "REST_TEST_TOKEN", "state": "printers.0.jobs"},
+			state:      `["a","b"]`,
+			attributes: map[string]interface{}{},
+		},
+		{
+			name:       "plain text",
+			meta:       entity.Meta{"url": server.URL + "/version"},
+			state:      "1.2.3",
+			attributes: map[string]interface{}{},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			p, err := NewRest(tc.meta).Run(context.Background())
+			require.NoError(t, err)
+			require.Equal(t, tc.state, p.State)
+			require.EqualValues(t, tc.attributes, p.Attributes)
+		})
+	}
+}
+
+func TestRestErrors(t *testing.T) {
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, _ *http.Request) {
+		w.WriteHeader(http.StatusForbidden)
+	}))
+	defer server.Close()
+
+	_, err := NewRest(entity.Meta{"url": server.URL}).Run(context.Background())
+	require.EqualError(t, err, "request to "+server.URL+" failed with status 403 Forbidden")
+	_, err = NewRest(entity.Meta{"url": server.URL, "token_env": "REST_TEST_UNSET"}).Run(context.Background())
+	require.EqualError(t, err, "environment variable REST_TEST_UNSET is not set")
+	_, err = NewRest(entity.Meta{}).Run(context.Background())
+	require.Error(t, err)
+	_, err = NewRest(entity.Meta{"state": "a.b"}).process([]byte(`{"a":{}}`))
+	require.EqualError(t, err, "JSON path a.b: key b does not exist")
+}
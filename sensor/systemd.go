@@ -0,0 +1,210 @@
+package sensor
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync"
+	"time"
+
+	"hacompanion/entity"
+
+	"github.com/godbus/dbus/v5"
+)
+
+const (
+	systemdDestination      = "org.freedesktop.systemd1"
+	systemdPath             = "/org/freedesktop/systemd1"
+	systemdUnitPath         = "/org/freedesktop/systemd1/unit"
+	systemdManagerInterface = "org.freedesktop.systemd1.Manager"
+	systemdUnitInterface    = "org.freedesktop.systemd1.Unit"
+)
+
+// SystemdUnits reports the state of systemd units on the system and user bus.
+type SystemdUnits struct {
+	units map[string][]string
+	conns map[string]*busConnection
+}
+
+func NewSystemdUnits(m entity.Meta) *SystemdUnits {
+	return &SystemdUnits{
+		units: map[string][]string{
+			busSystem:  m.GetStringSlice("system_units"),
+			busSession: m.GetStringSlice("user_units"),
+		},
+		conns: map[string]*busConnection{
+			busSystem:  newBusConnection(busSystem),
+			busSession: newBusConnection(busSession),
+		},
+	}
+}
+
+// systemdBusState contains the failed units and the monitored units of a single bus.
+type systemdBusState struct {
+	failed uint32
+	units  map[string]systemdUnitState
+}
+
+// systemdUnitState contains the properties of a single unit.
+type systemdUnitState struct {
+	activeState string
+	subState    string
+	loadState   string
+	// changedAt is the time of the last state change in µs since the epoch.
+	changedAt uint64
+}
+
+func (s *SystemdUnits) Run(ctx context.Context) (*entity.Payload, error) {
+	buses := make(map[string]systemdBusState, len(s.units))
+	for bus, units := range s.units {
+		conn, err := s.conns[bus].get()
+		if err != nil {
+			// The user bus is optional, as long as no user units are monitored.
+			if bus == busSession && len(units) == 0 {
+				continue
+			}
+			return nil, err
+		}
+		state := systemdBusState{units: make(map[string]systemdUnitState, len(units))}
+		manager := conn.Object(systemdDestination, systemdPath)
+		if err = manager.StoreProperty(systemdManagerInterface+".NFailedUnits", &state.failed); err != nil {
+			return nil, fmt.Errorf("failed to get failed units on the %s bus: %w", bus, err)
+		}
+		for _, unit := range units {
+			state.units[unit], err = s.unitState(ctx, conn, unit)
+			if err != nil {
+				return nil, fmt.Errorf("failed to get state of unit %s on the %s bus: %w", unit, bus, err)
+			}
+		}
+		buses[bus] = state
+	}
+	return s.process(buses, time.Now()), nil
+}
+
+// unitState returns the active state of a single unit and the time it last changed.
+func (s *SystemdUnits) unitState(ctx context.Context, conn *dbus.Conn, unit string) (systemdUnitState, error) {
+	var path dbus.ObjectPath
+	// LoadUnit succeeds for units that are not loaded at the moment, unlike GetUnit.
+	err := conn.Object(systemdDestination, systemdPath).
+		CallWithContext(ctx, systemdManagerInterface+".LoadUnit", 0, unit).
+		Store(&path)
+	if err != nil {
+		return systemdUnitState{}, err
+	}
+	obj := conn.Object(systemdDestination, path)
+	var state systemdUnitState
+	props := map[string]interface{}{
+		"ActiveState":          &state.activeState,
+		"SubState":             &state.subState,
+		"LoadState":            &state.loadState,
+		"StateChangeTimestamp": &state.changedAt,
+	}
+	for name, value := range props {
+		if err = obj.StoreProperty(systemdUnitInterface+"."+name, value); err != nil {
+			return systemdUnitState{}, err
+		}
+	}
+	return state, nil
+}
+
+func (s *SystemdUnits) process(buses map[string]systemdBusState, now time.Time) *entity.Payload {
+	p := entity.NewPayload()
+	var failed uint32
+	for bus, state := range buses {
+		prefix := bus
+		if bus == busSession {
+			prefix = "user"
+		}
+		failed += state.failed
+		p.Attributes[fmt.Sprintf("failed_%s_units", prefix)] = state.failed
+		if len(state.units) == 0 {
+			continue
+		}
+		units := make(map[string]interface{}, len(state.units))
+		for name, unit := range state.units {
+			attributes := map[string]interface{}{
+				"active_state": unit.activeState,
+				"sub_state":    unit.subState,
+				"load_state":   unit.loadState,
+			}
+			// The timestamp is zero if the unit never changed its state since boot.
+			if unit.changedAt > 0 {
+				//nolint:gosec
+				changed := time.UnixMicro(int64(unit.changedAt))
+				attributes["state_changed"] = changed.Format(time.RFC3339)
+				attributes["seconds_since_change"] = int(now.Sub(changed).Seconds())
+			}
+			units[name] = attributes
+		}
+		p.Attributes[prefix+"_units"] = units
+	}
+	p.State = failed
+	if failed > 0 {
+		p.Icon = "mdi:cog-off"
+	}
+	return p
+}
+
+// Watch pushes updates whenever a monitored unit changes its state or any unit fails.
+func (s *SystemdUnits) Watch(ctx context.Context, notify func()) error {
+	var wg sync.WaitGroup
+	var mu sync.Mutex
+	var errs []error
+	for bus, units := range s.units {
+		if bus == busSession && len(units) == 0 {
+			continue
+		}
+		wg.Add(1)
+		go func(bus string, units []string) {
+			defer wg.Done()
+			if err := s.watchBus(ctx, bus, units, notify); err != nil {
+				mu.Lock()
+				errs = append(errs, fmt.Errorf("%s bus: %w", bus, err))
+				mu.Unlock()
+			}
+		}(bus, units)
+	}
+	wg.Wait()
+	return errors.Join(errs...)
+}
+
+func (s *SystemdUnits) watchBus(ctx context.Context, bus string, units []string, notify func()) error {
+	conn, err := connectBus(bus)
+	if err != nil {
+		return err
+	}
+	defer conn.Close()
+
+	manager := conn.Object(systemdDestination, systemdPath)
+	// systemd only emits signals for units if there is at least one subscriber.
+	if err = manager.CallWithContext(ctx, systemdManagerInterface+".Subscribe", 0).Err; err != nil {
+		return fmt.Errorf("failed to subscribe to systemd: %w", err)
+	}
+	watched := make(map[dbus.ObjectPath]bool, len(units))
+	for _, unit := range units {
+		var path dbus.ObjectPath
+		if err = manager.CallWithContext(ctx, systemdManagerInterface+".LoadUnit", 0, unit).Store(&path); err != nil {
+			return fmt.Errorf("failed to load unit %s: %w", unit, err)
+		}
+		watched[path] = true
+	}
+
+	return watchSignals(ctx, conn, func(sig *dbus.Signal) {
+		iface, changed, ok := changedProperties(sig)
+		if !ok || iface != systemdUnitInterface {
+			return
+		}
+		state, ok := changed["ActiveState"]
+		if !ok {
+			return
+		}
+		if watched[sig.Path] || state == "failed" {
+			notify()
+		}
+	},
+		dbus.WithMatchSender(systemdDestination),
+		dbus.WithMatchInterface(dbusPropertiesInterface),
+		dbus.WithMatchMember(dbusPropertiesChanged),
+		dbus.WithMatchPathNamespace(systemdUnitPath),
+	)
+}
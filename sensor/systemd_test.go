@@ -0,0 +1,87 @@
+package sensor
+
+import (
+	"testing"
+	"time"
+
+	"hacompanion/entity"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestSystemdUnitsProcess(t *testing.T) {
+	now := time.Date(2024, 6, 1, 12, 0, 0, 0, time.UTC)
+	changed := now.Add(-90 * time.Second)
+	cases := []struct {
+		name  string
+		buses map[string]systemdBusState
+		want  *entity.Payload
+	}{
+		{
+			name: "no failed units",
+			buses: map[string]systemdBusState{
+				busSystem: {},
+			},
+			want: &entity.Payload{
+				State:      uint32(0),
+				Attributes: map[string]interface{}{"failed_system_units": uint32(0)},
+			},
+		},
+		{
+			name: "failed units on both buses",
+			buses: map[string]systemdBusState{
+				busSystem:  {failed: 2},
+				busSession: {failed: 1},
+			},
+			want: &entity.Payload{
+				State: uint32(3),
+				Icon:  "mdi:cog-off",
+				Attributes: map[string]interface{}{
+					"failed_system_units": uint32(2),
+					"failed_user_units":   uint32(1),
+				},
+			},
+		},
+		{
+			name: "monitored units",
+			buses: map[string]systemdBusState{
+				busSystem: {units: map[string]systemdUnitState{
+					"sshd.service": {activeState: "active", subState: "running", loadState: "loaded", changedAt: uint64(changed.UnixMicro())},
+				}},
+				busSession: {failed: 1, units: map[string]systemdUnitState{
+					// The timestamp is zero if the unit never changed its state.
+					"syncthing.service": {activeState: "inactive", subState: "dead", loadState: "not-found"},
+				}},
+			},
+			want: &entity.Payload{
+				State: uint32(1),
+				Icon:  "mdi:cog-off",
+				Attributes: map[string]interface{}{
+					"failed_system_units": uint32(0),
+					"failed_user_units":   uint32(1),
+					"system_units": map[string]interface{}{
+						"sshd.service": map[string]interface{}{
+							"active_state":         "active",
+							"sub_state":            "running",
+							"load_state":           "loaded",
+							"state_changed":        changed.Local().Format(time.RFC3339),
+							"seconds_since_change": 90,
+						},
+					},
+					"user_units": map[string]interface{}{
+						"syncthing.service": map[string]interface{}{
+							"active_state": "inactive",
+							"sub_state":    "dead",
+							"load_state":   "not-found",
+						},
+					},
+				},
+			},
+		},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			require.Equal(t, tc.want, (&SystemdUnits{}).process(tc.buses, now))
+		})
+	}
+}
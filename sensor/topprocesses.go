@@ -0,0 +1,118 @@
+package sensor
+
+import (
+	"context"
+	"os/user"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"hacompanion/entity"
+	"hacompanion/util"
+)
+
+// TopProcesses reports the processes with the highest CPU and memory usage.
+type TopProcesses struct {
+	table *processTable
+	cpu   cpuTracker
+	count int
+
+	mu    sync.Mutex
+	users map[uint32]string
+}
+
+func NewTopProcesses(m entity.Meta) *TopProcesses {
+	t := &TopProcesses{
+		table: procTable,
+		count: 5,
+		users: make(map[uint32]string),
+	}
+	if count := m.GetInt("count"); count > 0 {
+		t.count = count
+	}
+	return t
+}
+
+func (t *TopProcesses) Run(ctx context.Context) (*entity.Payload, error) {
+	processes, takenAt, err := t.table.snapshot()
+	if err != nil {
+		return nil, err
+	}
+	return t.process(processes, takenAt), nil
+}
+
+func (t *TopProcesses) process(processes []processInfo, takenAt time.Time) *entity.Payload {
+	usage := t.cpu.update(processes, takenAt)
+
+	byCPU := make([]processInfo, len(processes))
+	copy(byCPU, processes)
+	sort.SliceStable(byCPU, func(i, j int) bool {
+		return usage[byCPU[i].PID] > usage[byCPU[j].PID]
+	})
+	byMemory := make([]processInfo, len(processes))
+	copy(byMemory, processes)
+	sort.SliceStable(byMemory, func(i, j int) bool {
+		return byMemory[i].RSS > byMemory[j].RSS
+	})
+
+	topCPU := make([]map[string]interface{}, 0, t.count)
+	for _, proc := range byCPU {
+		if len(topCPU) >= t.count {
+			break
+		}
+		// Processes without usage data are not worth reporting.
+		if usage[proc.PID] <= 0 {
+			break
+		}
+		topCPU = append(topCPU, map[string]interface{}{
+			"name":        proc.Name,
+			"pid":         proc.PID,
+			"user":        t.userName(proc.UID),
+			"cpu_percent": util.RoundToTwoDecimals(usage[proc.PID]),
+		})
+	}
+	topMemory := make([]map[string]interface{}, 0, t.count)
+	for _, proc := range byMemory {
+		if len(topMemory) >= t.count || proc.RSS == 0 {
+			break
+		}
+		topMemory = append(topMemory, map[string]interface{}{
+			"name": proc.Name,
+			"pid":  proc.PID,
+			"user": t.userName(proc.UID),
+			// Convert bytes to MB.
+			"memory_rss": util.RoundToTwoDecimals(float64(proc.RSS) / 1024 / 1024),
+		})
+	}
+
+	p := entity.NewPayload()
+	// The top CPU consumer is unknown on the first run, use the top memory consumer instead.
+	switch {
+	case len(topCPU) > 0:
+		p.State = topCPU[0]["name"]
+	case len(topMemory) > 0:
+		p.State = topMemory[0]["name"]
+	default:
+		p.State = "unknown"
+	}
+	p.Attributes["top_cpu"] = topCPU
+	p.Attributes["top_memory"] = topMemory
+	return p
+}
+
+// userName resolves a user ID to its name. Lookups are cached,
+// since /etc/passwd would otherwise be read for every process.
+func (t *TopProcesses) userName(uid uint32) string {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if name, ok := t.users[uid]; ok {
+		return name
+	}
+	name := strconv.FormatUint(uint64(uid), 10)
+	if u, err := user.LookupId(name); err == nil {
+		name = u.Username
+	}
+	t.users[uid] = name
+	return name
+}
@@ -0,0 +1,45 @@
+package sensor
+
+import (
+	"testing"
+	"time"
+
+	"hacompanion/entity"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestTopProcesses(t *testing.T) {
+	now := time.Now()
+	first := []processInfo{
+		{PID: 1, UID: 0, Name: "systemd", CPUTicks: 100, StartTicks: 1, RSS: 12 * 1024 * 1024},
+		{PID: 200, UID: 1000, Name: "firefox", CPUTicks: 1000, StartTicks: 500, RSS: 800 * 1024 * 1024},
+		{PID: 300, UID: 1000, Name: "cc1plus", CPUTicks: 50, StartTicks: 900, RSS: 200 * 1024 * 1024},
+	}
+	second := []processInfo{
+		{PID: 1, UID: 0, Name: "systemd", CPUTicks: 100, StartTicks: 1, RSS: 12 * 1024 * 1024},
+		{PID: 200, UID: 1000, Name: "firefox", CPUTicks: 1100, StartTicks: 500, RSS: 800 * 1024 * 1024},
+		{PID: 300, UID: 1000, Name: "cc1plus", CPUTicks: 1050, StartTicks: 900, RSS: 200 * 1024 * 1024},
+	}
+	output := &entity.Payload{
+		State: "cc1plus",
+		Attributes: map[string]interface{}{
+			"top_cpu": []map[string]interface{}{
+				{"name": "cc1plus", "pid": 300, "user": "dev", "cpu_percent": float64(100)},
+				{"name": "firefox", "pid": 200, "user": "dev", "cpu_percent": float64(10)},
+			},
+			"top_memory": []map[string]interface{}{
+				{"name": "firefox", "pid": 200, "user": "dev", "memory_rss": float64(800)},
+				{"name": "cc1plus", "pid": 300, "user": "dev", "memory_rss": float64(200)},
+			},
+		},
+	}
+
+	top := NewTopProcesses(entity.Meta{"count": int64(2)})
+	top.users = map[uint32]string{0: "root", 1000: "dev"}
+
+	res := top.process(first, now)
+	require.Equal(t, "firefox", res.State)
+	res = top.process(second, now.Add(10*time.Second))
+	require.EqualValues(t, output, res)
+}
@@ -2,48 +2,111 @@ package sensor
 
 import (
 	"bufio"
-	"bytes"
 	"context"
-	"errors"
-	"fmt"
-	"os/exec"
+	"os"
+	"path/filepath"
+	"strconv"
 	"strings"
 
 	"hacompanion/entity"
 )
 
-type WebCam struct{}
+// WebCam reports the processes that are accessing a video device.
+type WebCam struct {
+	table  *processTable
+	binary bool
+}
 
-func NewWebCam() *WebCam {
-	return &WebCam{}
+func NewWebCam(m entity.Meta) *WebCam {
+	return &WebCam{
+		table:  procTable,
+		binary: m.GetBool("binary"),
+	}
 }
 
 func (w WebCam) Run(ctx context.Context) (*entity.Payload, error) {
-	var err error
-	var out bytes.Buffer
-	cmd := exec.CommandContext(ctx, "lsmod")
-	cmd.Stdout = &out
-	if err = cmd.Run(); err != nil {
+	processes, _, err := w.table.snapshot()
+	if err != nil {
 		return nil, err
 	}
-	var procCount string
-	prefix := []byte("uvcvideo")
-	scanner := bufio.NewScanner(&out)
-	for scanner.Scan() {
-		if !bytes.HasPrefix(scanner.Bytes(), prefix) {
+	users := w.videoDeviceUsers(processes)
+
+	var names, devices []string
+	for _, user := range users {
+		names = append(names, user.name)
+		devices = append(devices, user.devices...)
+	}
+
+	p := entity.NewPayload()
+	if w.binary {
+		p.State = len(users) > 0
+		p.Attributes["process_count"] = len(users)
+	} else {
+		p.State = len(users)
+	}
+	p.Attributes["processes"] = uniqueSorted(names)
+	p.Attributes["devices"] = uniqueSorted(devices)
+	// The reference count of the UVC driver is kept for compatibility with earlier versions.
+	if refCount, ok := w.moduleRefCount("uvcvideo"); ok {
+		p.Attributes["uvcvideo_refcount"] = refCount
+	}
+	if len(users) > 0 {
+		p.Icon = "mdi:webcam"
+	} else {
+		p.Icon = "mdi:webcam-off"
+	}
+	return p, nil
+}
+
+type videoDeviceUser struct {
+	pid     int
+	name    string
+	devices []string
+}
+
+// videoDeviceUsers returns all processes that have a /dev/video* device open.
+// Only processes of the current user can be inspected, unless the companion runs as root.
+func (w WebCam) videoDeviceUsers(processes []processInfo) []videoDeviceUser {
+	var users []videoDeviceUser
+	for _, proc := range processes {
+		fdDir := filepath.Join(w.table.root, strconv.Itoa(proc.PID), "fd")
+		fds, err := os.ReadDir(fdDir)
+		if err != nil {
 			continue
 		}
-		fields := strings.Fields(scanner.Text())
-		if len(fields) < 3 {
-			return nil, fmt.Errorf("expected three values for lsmod uvcvideo entry, got %s", scanner.Text())
+		var devices []string
+		for _, fd := range fds {
+			target, err := os.Readlink(filepath.Join(fdDir, fd.Name()))
+			if err != nil || !strings.HasPrefix(target, "/dev/video") {
+				continue
+			}
+			devices = append(devices, target)
+		}
+		if len(devices) > 0 {
+			users = append(users, videoDeviceUser{pid: proc.PID, name: proc.Name, devices: devices})
 		}
-		procCount = fields[2]
-		break
 	}
-	if procCount == "" {
-		return nil, errors.New("did no find uvcvideo in lsmod output, failed to determine webcam usage")
+	return users
+}
+
+// moduleRefCount returns the number of references to a kernel module from /proc/modules.
+func (w WebCam) moduleRefCount(module string) (int, bool) {
+	f, err := os.Open(filepath.Join(w.table.root, "modules"))
+	if err != nil {
+		return 0, false
 	}
-	p := entity.NewPayload()
-	p.State = procCount
-	return p, nil
+	defer f.Close()
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 3 || fields[0] != module {
+			continue
+		}
+		refCount, err := strconv.Atoi(fields[2])
+		if err != nil {
+			return 0, false
+		}
+		return refCount, true
+	}
+	return 0, false
 }
@@ -0,0 +1,49 @@
+package sensor
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"hacompanion/entity"
+
+	"github.com/stretchr/testify/require"
+)
+
+func TestWebCam(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]map[string]string{
+		"100": {"0": "/dev/null", "1": "/dev/video0", "2": "/dev/video1"},
+		"200": {"0": "/dev/pts/1", "5": "socket:[12345]"},
+		"300": {"7": "/dev/video0"},
+	}
+	for pid, fds := range files {
+		dir := filepath.Join(root, pid, "fd")
+		require.NoError(t, os.MkdirAll(dir, 0o755))
+		for fd, target := range fds {
+			require.NoError(t, os.Symlink(target, filepath.Join(dir, fd)))
+		}
+	}
+	modules := "uvcvideo 139264 2 - Live 0x0000000000000000\nvideodev 352256 2 uvcvideo, Live 0x0000000000000000\n"
+	require.NoError(t, os.WriteFile(filepath.Join(root, "modules"), []byte(modules), 0o600))
+
+	processes := []processInfo{
+		{PID: 100, Name: "zoom"},
+		{PID: 200, Name: "bash"},
+		{PID: 300, Name: "obs"},
+		{PID: 400, Name: "vanished"},
+	}
+
+	w := NewWebCam(entity.Meta{})
+	w.table = newProcessTable(root)
+
+	users := w.videoDeviceUsers(processes)
+	require.Equal(t, []videoDeviceUser{
+		{pid: 100, name: "zoom", devices: []string{"/dev/video0", "/dev/video1"}},
+		{pid: 300, name: "obs", devices: []string{"/dev/video0"}},
+	}, users)
+
+	refCount, ok := w.moduleRefCount("uvcvideo")
+	require.True(t, ok)
+	require.Equal(t, 2, refCount)
+}